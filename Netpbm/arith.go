@@ -0,0 +1,185 @@
+package Netpbm
+
+import (
+	"errors"
+)
+
+// ArithOperation selects how the samples of two images are combined.
+type ArithOperation int
+
+const (
+	ArithAdd        ArithOperation = iota // a + b
+	ArithSubtract                         // a - b
+	ArithDifference                       // |a - b|
+	ArithMultiply                         // a * b / max
+	ArithMin                              // smallest of a and b
+	ArithMax                              // largest of a and b
+	ArithMean                             // (a + b) / 2, rounded down
+)
+
+// Overflow selects what happens to results outside of [0, max].
+type Overflow int
+
+const (
+	OverflowSaturate Overflow = iota // clip to 0 or max
+	OverflowWrap                     // keep the result modulo max + 1
+)
+
+// arith combines samples a and b in [0, max].
+func arith(a, b, max int, op ArithOperation, overflow Overflow) uint8 {
+	var v int
+	switch op {
+	case ArithAdd:
+		v = a + b
+	case ArithSubtract:
+		v = a - b
+	case ArithDifference:
+		v = a - b
+		if v < 0 {
+			v = -v
+		}
+	case ArithMultiply:
+		if max > 0 {
+			v = (a*b + max/2) / max
+		}
+	case ArithMin:
+		v = a
+		if b < a {
+			v = b
+		}
+	case ArithMax:
+		v = a
+		if b > a {
+			v = b
+		}
+	case ArithMean:
+		v = (a + b) / 2
+	}
+	if overflow == OverflowWrap {
+		v %= max + 1
+		if v < 0 {
+			v += max + 1
+		}
+		return uint8(v)
+	}
+	if v < 0 {
+		return 0
+	}
+	if v > max {
+		return uint8(max)
+	}
+	return uint8(v)
+}
+
+// checkArith returns an error for an invalid operation or overflow.
+func checkArith(op ArithOperation, overflow Overflow) error {
+	if op < ArithAdd || op > ArithMean {
+		return errors.New("invalid arithmetic operation")
+	}
+	if overflow != OverflowSaturate && overflow != OverflowWrap {
+		return errors.New("invalid overflow mode")
+	}
+	return nil
+}
+
+// ArithPGM combines two PGM images of the same size sample by sample, like
+// pamarith. The result uses the largest max value of the two, samples of the
+// other being rescaled, and the magic number of a.
+func ArithPGM(a, b *PGM, op ArithOperation, overflow Overflow) (*PGM, error) {
+	if a.width != b.width || a.height != b.height {
+		return nil, errors.New("images do not have the same size")
+	}
+	if err := checkArith(op, overflow); err != nil {
+		return nil, err
+	}
+	max := a.max
+	if b.max > max {
+		max = b.max
+	}
+	pgm := &PGM{
+		data:        make([][]uint8, a.height),
+		width:       a.width,
+		height:      a.height,
+		magicNumber: a.magicNumber,
+		max:         max,
+	}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, a.width)
+		for x := range pgm.data[y] {
+			va, vb := rescale(a.data[y][x], a.max, max), rescale(b.data[y][x], b.max, max)
+			pgm.data[y][x] = arith(int(va), int(vb), int(max), op, overflow)
+		}
+	}
+	return pgm, nil
+}
+
+// ArithPPM combines two PPM images of the same size channel by channel; see
+// ArithPGM.
+func ArithPPM(a, b *PPM, op ArithOperation, overflow Overflow) (*PPM, error) {
+	if a.width != b.width || a.height != b.height {
+		return nil, errors.New("images do not have the same size")
+	}
+	if err := checkArith(op, overflow); err != nil {
+		return nil, err
+	}
+	max := a.max
+	if b.max > max {
+		max = b.max
+	}
+	ppm := &PPM{
+		data:        make([][]Pixel, a.height),
+		width:       a.width,
+		height:      a.height,
+		magicNumber: a.magicNumber,
+		max:         max,
+	}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, a.width)
+		for x := range ppm.data[y] {
+			pa, pb := a.data[y][x], b.data[y][x]
+			combine := func(va, vb uint8) uint8 {
+				return arith(int(rescale(va, a.max, max)), int(rescale(vb, b.max, max)), int(max), op, overflow)
+			}
+			ppm.data[y][x] = Pixel{R: combine(pa.R, pb.R), G: combine(pa.G, pb.G), B: combine(pa.B, pb.B)}
+		}
+	}
+	return ppm, nil
+}
+
+// logicPBM combines two PBM images of the same size pixel by pixel with f.
+func logicPBM(a, b *PBM, f func(a, b bool) bool) (*PBM, error) {
+	if a.width != b.width || a.height != b.height {
+		return nil, errors.New("images do not have the same size")
+	}
+	pbm := &PBM{
+		data:        make([][]bool, a.height),
+		width:       a.width,
+		height:      a.height,
+		magicNumber: a.magicNumber,
+	}
+	for y := range pbm.data {
+		pbm.data[y] = make([]bool, a.width)
+		for x := range pbm.data[y] {
+			pbm.data[y][x] = f(a.data[y][x], b.data[y][x])
+		}
+	}
+	return pbm, nil
+}
+
+// AndPBM returns the PBM image whose pixels are black where both images are
+// black.
+func AndPBM(a, b *PBM) (*PBM, error) {
+	return logicPBM(a, b, func(a, b bool) bool { return a && b })
+}
+
+// OrPBM returns the PBM image whose pixels are black where either image is
+// black.
+func OrPBM(a, b *PBM) (*PBM, error) {
+	return logicPBM(a, b, func(a, b bool) bool { return a || b })
+}
+
+// XorPBM returns the PBM image whose pixels are black where exactly one of
+// the images is black, which shows the pixels that changed.
+func XorPBM(a, b *PBM) (*PBM, error) {
+	return logicPBM(a, b, func(a, b bool) bool { return a != b })
+}
@@ -0,0 +1,82 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestArithPGM(t *testing.T) {
+	a := &PGM{data: [][]uint8{{200, 50, 10}}, width: 3, height: 1, magicNumber: "P2", max: 255}
+	b := &PGM{data: [][]uint8{{100, 100, 255}}, width: 3, height: 1, magicNumber: "P2", max: 255}
+	tests := []struct {
+		op       ArithOperation
+		overflow Overflow
+		expected []uint8
+	}{
+		{ArithAdd, OverflowSaturate, []uint8{255, 150, 255}},
+		{ArithAdd, OverflowWrap, []uint8{44, 150, 9}},
+		{ArithSubtract, OverflowSaturate, []uint8{100, 0, 0}},
+		{ArithSubtract, OverflowWrap, []uint8{100, 206, 11}},
+		{ArithDifference, OverflowSaturate, []uint8{100, 50, 245}},
+		{ArithMultiply, OverflowSaturate, []uint8{78, 20, 10}},
+		{ArithMin, OverflowSaturate, []uint8{100, 50, 10}},
+		{ArithMax, OverflowSaturate, []uint8{200, 100, 255}},
+		{ArithMean, OverflowSaturate, []uint8{150, 75, 132}},
+	}
+	for _, test := range tests {
+		pgm, err := ArithPGM(a, b, test.op, test.overflow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x, v := range test.expected {
+			if pgm.data[0][x] != v {
+				t.Errorf("Operation %d, overflow %d: got %v, expected %v", test.op, test.overflow, pgm.data[0], test.expected)
+				break
+			}
+		}
+	}
+
+	// b is rescaled to the max value of a
+	small := &PGM{data: [][]uint8{{1, 0, 1}}, width: 3, height: 1, magicNumber: "P2", max: 1}
+	pgm, err := ArithPGM(a, small, ArithMin, OverflowSaturate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pgm.max != 255 || pgm.data[0][0] != 200 || pgm.data[0][1] != 0 {
+		t.Errorf("Got %v with max %d", pgm.data[0], pgm.max)
+	}
+	if _, err := ArithPGM(a, &PGM{data: [][]uint8{{1}}, width: 1, height: 1, max: 1}, ArithAdd, OverflowSaturate); err == nil {
+		t.Error("Expected an error for mismatched sizes")
+	}
+	if _, err := ArithPGM(a, b, ArithOperation(42), OverflowSaturate); err == nil {
+		t.Error("Expected an error for an invalid operation")
+	}
+}
+
+func TestArithPPM(t *testing.T) {
+	a := &PPM{data: [][]Pixel{{{10, 20, 30}}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	b := &PPM{data: [][]Pixel{{{30, 20, 10}}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	ppm, err := ArithPPM(a, b, ArithDifference, OverflowSaturate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ppm.data[0][0] != (Pixel{20, 0, 20}) {
+		t.Errorf("Got %v", ppm.data[0][0])
+	}
+}
+
+func TestLogicPBM(t *testing.T) {
+	a := bitmap("##..")
+	b := bitmap("#.#.")
+	and, _ := AndPBM(a, b)
+	or, _ := OrPBM(a, b)
+	xor, err := XorPBM(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareBitmap(t, and, "#...")
+	compareBitmap(t, or, "###.")
+	compareBitmap(t, xor, ".##.")
+	if _, err := AndPBM(a, bitmap("#")); err == nil {
+		t.Error("Expected an error for mismatched sizes")
+	}
+}
@@ -0,0 +1,298 @@
+package Netpbm
+
+import "errors"
+
+// Direction selects the axis along which images are concatenated.
+type Direction int
+
+const (
+	DirectionHorizontal Direction = iota // side by side, left to right
+	DirectionVertical                    // stacked, top to bottom
+)
+
+// Alignment places an image that is smaller than the others across the
+// concatenation axis.
+type Alignment int
+
+const (
+	AlignStart  Alignment = iota // top or left
+	AlignCenter                  // centred
+	AlignEnd                     // bottom or right
+)
+
+// padIndex returns the source index read for the destination index i of a
+// line of n source samples shifted by offset. ok is false when the fill
+// value must be used.
+func padIndex(i, offset, n int, edge EdgeMode) (int, bool) {
+	return edgeIndex(i-offset, n, edge)
+}
+
+// checkPadding returns an error if one of the margins is negative.
+func checkPadding(top, right, bottom, left int) error {
+	if top < 0 || right < 0 || bottom < 0 || left < 0 {
+		return errors.New("padding must not be negative")
+	}
+	return nil
+}
+
+// alignOffset returns where a line of size n starts inside a line of size total.
+func alignOffset(n, total int, align Alignment) int {
+	switch align {
+	case AlignCenter:
+		return (total - n) / 2
+	case AlignEnd:
+		return total - n
+	}
+	return 0
+}
+
+// rescale converts the sample v from the range [0, from] to [0, to].
+func rescale(v, from, to uint8) uint8 {
+	if from == to || from == 0 {
+		return v
+	}
+	return uint8(uint32(v) * uint32(to) / uint32(from))
+}
+
+// Pad adds margins of the given sizes around the PBM image. The margins are
+// filled according to edge, fill being used by EdgeConstant.
+func (pbm *PBM) Pad(top, right, bottom, left int, edge EdgeMode, fill bool) error {
+	if err := checkPadding(top, right, bottom, left); err != nil {
+		return err
+	}
+	width, height := pbm.width+left+right, pbm.height+top+bottom
+	data := make([][]bool, height)
+	for y := range data {
+		data[y] = make([]bool, width)
+		sy, oky := padIndex(y, top, pbm.height, edge)
+		for x := range data[y] {
+			sx, okx := padIndex(x, left, pbm.width, edge)
+			if oky && okx {
+				data[y][x] = pbm.data[sy][sx]
+			} else {
+				data[y][x] = fill
+			}
+		}
+	}
+	pbm.width, pbm.height = width, height
+	pbm.data = data
+	return nil
+}
+
+// Border frames the PBM image with a margin of the given size and colour.
+func (pbm *PBM) Border(size int, fill bool) error {
+	return pbm.Pad(size, size, size, size, EdgeConstant, fill)
+}
+
+// Pad adds margins of the given sizes around the PGM image. The margins are
+// filled according to edge, fill being used by EdgeConstant.
+func (pgm *PGM) Pad(top, right, bottom, left int, edge EdgeMode, fill uint8) error {
+	if err := checkPadding(top, right, bottom, left); err != nil {
+		return err
+	}
+	width, height := pgm.width+left+right, pgm.height+top+bottom
+	data := make([][]uint8, height)
+	for y := range data {
+		data[y] = make([]uint8, width)
+		sy, oky := padIndex(y, top, pgm.height, edge)
+		for x := range data[y] {
+			sx, okx := padIndex(x, left, pgm.width, edge)
+			if oky && okx {
+				data[y][x] = pgm.data[sy][sx]
+			} else {
+				data[y][x] = fill
+			}
+		}
+	}
+	pgm.width, pgm.height = width, height
+	pgm.data = data
+	return nil
+}
+
+// Border frames the PGM image with a margin of the given size and value.
+func (pgm *PGM) Border(size int, fill uint8) error {
+	return pgm.Pad(size, size, size, size, EdgeConstant, fill)
+}
+
+// Pad adds margins of the given sizes around the PPM image. The margins are
+// filled according to edge, fill being used by EdgeConstant.
+func (ppm *PPM) Pad(top, right, bottom, left int, edge EdgeMode, fill Pixel) error {
+	if err := checkPadding(top, right, bottom, left); err != nil {
+		return err
+	}
+	width, height := ppm.width+left+right, ppm.height+top+bottom
+	data := make([][]Pixel, height)
+	for y := range data {
+		data[y] = make([]Pixel, width)
+		sy, oky := padIndex(y, top, ppm.height, edge)
+		for x := range data[y] {
+			sx, okx := padIndex(x, left, ppm.width, edge)
+			if oky && okx {
+				data[y][x] = ppm.data[sy][sx]
+			} else {
+				data[y][x] = fill
+			}
+		}
+	}
+	ppm.width, ppm.height = width, height
+	ppm.data = data
+	return nil
+}
+
+// Border frames the PPM image with a margin of the given size and colour.
+func (ppm *PPM) Border(size int, fill Pixel) error {
+	return ppm.Pad(size, size, size, size, EdgeConstant, fill)
+}
+
+// concatLayout computes the size of the concatenation of images of the given
+// sizes and the top-left corner of each of them.
+func concatLayout(widths, heights []int, direction Direction, align Alignment) (width, height int, xs, ys []int) {
+	xs = make([]int, len(widths))
+	ys = make([]int, len(widths))
+	for i := range widths {
+		if direction == DirectionHorizontal {
+			width += widths[i]
+			if heights[i] > height {
+				height = heights[i]
+			}
+		} else {
+			height += heights[i]
+			if widths[i] > width {
+				width = widths[i]
+			}
+		}
+	}
+	var pos int
+	for i := range widths {
+		if direction == DirectionHorizontal {
+			xs[i], ys[i] = pos, alignOffset(heights[i], height, align)
+			pos += widths[i]
+		} else {
+			xs[i], ys[i] = alignOffset(widths[i], width, align), pos
+			pos += heights[i]
+		}
+	}
+	return width, height, xs, ys
+}
+
+// ConcatPBM joins PBM images side by side or on top of each other. Images
+// smaller across the concatenation axis are placed according to align and
+// the remaining space is set to fill.
+func ConcatPBM(images []*PBM, direction Direction, align Alignment, fill bool) (*PBM, error) {
+	if len(images) == 0 {
+		return nil, errors.New("no image to concatenate")
+	}
+	widths := make([]int, len(images))
+	heights := make([]int, len(images))
+	for i, img := range images {
+		widths[i], heights[i] = img.width, img.height
+	}
+	width, height, xs, ys := concatLayout(widths, heights, direction, align)
+	pbm := &PBM{
+		data:        make([][]bool, height),
+		width:       width,
+		height:      height,
+		magicNumber: images[0].magicNumber,
+	}
+	for y := range pbm.data {
+		pbm.data[y] = make([]bool, width)
+		for x := range pbm.data[y] {
+			pbm.data[y][x] = fill
+		}
+	}
+	for i, img := range images {
+		for y := 0; y < img.height; y++ {
+			copy(pbm.data[ys[i]+y][xs[i]:], img.data[y])
+		}
+	}
+	return pbm, nil
+}
+
+// ConcatPGM joins PGM images side by side or on top of each other. The
+// result uses the largest max value of the images, samples of the others
+// being rescaled. Images smaller across the concatenation axis are placed
+// according to align and the remaining space is set to fill, expressed in
+// the resulting range.
+func ConcatPGM(images []*PGM, direction Direction, align Alignment, fill uint8) (*PGM, error) {
+	if len(images) == 0 {
+		return nil, errors.New("no image to concatenate")
+	}
+	widths := make([]int, len(images))
+	heights := make([]int, len(images))
+	var max uint8
+	for i, img := range images {
+		widths[i], heights[i] = img.width, img.height
+		if img.max > max {
+			max = img.max
+		}
+	}
+	width, height, xs, ys := concatLayout(widths, heights, direction, align)
+	pgm := &PGM{
+		data:        make([][]uint8, height),
+		width:       width,
+		height:      height,
+		magicNumber: images[0].magicNumber,
+		max:         max,
+	}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, width)
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = fill
+		}
+	}
+	for i, img := range images {
+		for y := 0; y < img.height; y++ {
+			for x := 0; x < img.width; x++ {
+				pgm.data[ys[i]+y][xs[i]+x] = rescale(img.data[y][x], img.max, max)
+			}
+		}
+	}
+	return pgm, nil
+}
+
+// ConcatPPM joins PPM images side by side or on top of each other. The
+// result uses the largest max value of the images, samples of the others
+// being rescaled. Images smaller across the concatenation axis are placed
+// according to align and the remaining space is set to fill, expressed in
+// the resulting range.
+func ConcatPPM(images []*PPM, direction Direction, align Alignment, fill Pixel) (*PPM, error) {
+	if len(images) == 0 {
+		return nil, errors.New("no image to concatenate")
+	}
+	widths := make([]int, len(images))
+	heights := make([]int, len(images))
+	var max uint8
+	for i, img := range images {
+		widths[i], heights[i] = img.width, img.height
+		if img.max > max {
+			max = img.max
+		}
+	}
+	width, height, xs, ys := concatLayout(widths, heights, direction, align)
+	ppm := &PPM{
+		data:        make([][]Pixel, height),
+		width:       width,
+		height:      height,
+		magicNumber: images[0].magicNumber,
+		max:         max,
+	}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, width)
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = fill
+		}
+	}
+	for i, img := range images {
+		for y := 0; y < img.height; y++ {
+			for x := 0; x < img.width; x++ {
+				p := img.data[y][x]
+				ppm.data[ys[i]+y][xs[i]+x] = Pixel{
+					R: rescale(p.R, img.max, max),
+					G: rescale(p.G, img.max, max),
+					B: rescale(p.B, img.max, max),
+				}
+			}
+		}
+	}
+	return ppm, nil
+}
@@ -0,0 +1,95 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestPadPGM(t *testing.T) {
+	expected := map[EdgeMode][]uint8{
+		EdgeConstant: {0, 0, 1, 2, 3, 0},
+		EdgeClamp:    {1, 1, 1, 2, 3, 3},
+		EdgeWrap:     {2, 3, 1, 2, 3, 1},
+		EdgeReflect:  {2, 1, 1, 2, 3, 3},
+	}
+	for edge, row := range expected {
+		pgm := &PGM{data: [][]uint8{{1, 2, 3}}, width: 3, height: 1, magicNumber: "P2", max: 3}
+		if err := pgm.Pad(1, 1, 0, 2, edge, 0); err != nil {
+			t.Fatal(err)
+		}
+		if pgm.width != 6 || pgm.height != 2 {
+			t.Fatalf("Wrong size %dx%d", pgm.width, pgm.height)
+		}
+		for x := range row {
+			if pgm.data[1][x] != row[x] {
+				t.Errorf("Edge mode %d: got %v, expected %v", edge, pgm.data[1], row)
+				break
+			}
+		}
+	}
+	pgm := &PGM{data: [][]uint8{{1}}, width: 1, height: 1, magicNumber: "P2", max: 3}
+	if err := pgm.Pad(-1, 0, 0, 0, EdgeClamp, 0); err == nil {
+		t.Error("Expected an error for a negative margin")
+	}
+}
+
+func TestBorderPBM(t *testing.T) {
+	pbm, err := ReadPBM("./testImages/pbm/testP1.pbm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pbm.Border(2, true); err != nil {
+		t.Fatal(err)
+	}
+	if pbm.width != imageWidth+4 || pbm.height != imageHeight+4 {
+		t.Fatalf("Wrong size %dx%d", pbm.width, pbm.height)
+	}
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
+		if pbm.data[y+2][x+2] != imageDataP1[i] {
+			t.Errorf("Pixel at (%d, %d) moved", x, y)
+		}
+	}
+	if !pbm.data[0][0] || !pbm.data[imageHeight+3][imageWidth+3] {
+		t.Error("Border not set")
+	}
+}
+
+func TestConcatPGM(t *testing.T) {
+	a := &PGM{data: [][]uint8{{1, 1}, {1, 1}}, width: 2, height: 2, magicNumber: "P2", max: 1}
+	b := &PGM{data: [][]uint8{{4}}, width: 1, height: 1, magicNumber: "P5", max: 4}
+	pgm, err := ConcatPGM([]*PGM{a, b}, DirectionHorizontal, AlignEnd, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]uint8{{4, 4, 0}, {4, 4, 4}}
+	if pgm.width != 3 || pgm.height != 2 || pgm.max != 4 || pgm.magicNumber != "P2" {
+		t.Fatalf("Wrong header %dx%d max %d", pgm.width, pgm.height, pgm.max)
+	}
+	for y := range expected {
+		for x := range expected[y] {
+			if pgm.data[y][x] != expected[y][x] {
+				t.Errorf("Pixel at (%d, %d) is %d, expected %d", x, y, pgm.data[y][x], expected[y][x])
+			}
+		}
+	}
+	if _, err := ConcatPGM(nil, DirectionVertical, AlignStart, 0); err == nil {
+		t.Error("Expected an error without images")
+	}
+}
+
+func TestConcatVerticalPPM(t *testing.T) {
+	red := Pixel{255, 0, 0}
+	a := &PPM{data: [][]Pixel{{red}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	b := &PPM{data: [][]Pixel{{red, red, red}}, width: 3, height: 1, magicNumber: "P3", max: 255}
+	ppm, err := ConcatPPM([]*PPM{a, b}, DirectionVertical, AlignCenter, Pixel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ppm.width != 3 || ppm.height != 2 {
+		t.Fatalf("Wrong size %dx%d", ppm.width, ppm.height)
+	}
+	if ppm.At(0, 0) != (Pixel{}) || ppm.At(1, 0) != red || ppm.At(2, 0) != (Pixel{}) {
+		t.Errorf("First row is %v", ppm.data[0])
+	}
+}
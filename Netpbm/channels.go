@@ -0,0 +1,143 @@
+package Netpbm
+
+import (
+	"errors"
+)
+
+// Channel identifies one of the colour channels of a PPM image.
+type Channel int
+
+const (
+	ChannelRed   Channel = iota // red, Pixel.R
+	ChannelGreen                // green, Pixel.G
+	ChannelBlue                 // blue, Pixel.B
+)
+
+// sample returns the value of the channel in p.
+func (c Channel) sample(p Pixel) uint8 {
+	switch c {
+	case ChannelGreen:
+		return p.G
+	case ChannelBlue:
+		return p.B
+	}
+	return p.R
+}
+
+// set sets the value of the channel in p.
+func (c Channel) set(p *Pixel, v uint8) {
+	switch c {
+	case ChannelGreen:
+		p.G = v
+	case ChannelBlue:
+		p.B = v
+	default:
+		p.R = v
+	}
+}
+
+// valid reports whether c is one of Red, Green and Blue.
+func (c Channel) valid() bool {
+	return c == ChannelRed || c == ChannelGreen || c == ChannelBlue
+}
+
+// grayMagicNumber returns the PGM magic number with the same encoding as a
+// PPM magic number.
+func grayMagicNumber(magicNumber string) string {
+	if magicNumber == "P6" {
+		return "P5"
+	}
+	return "P2"
+}
+
+// colorMagicNumber returns the PPM magic number with the same encoding as a
+// PGM magic number.
+func colorMagicNumber(magicNumber string) string {
+	if magicNumber == "P5" {
+		return "P6"
+	}
+	return "P3"
+}
+
+// Channel returns one channel of the PPM image as a PGM image with the same
+// max value.
+func (ppm *PPM) Channel(c Channel) (*PGM, error) {
+	if !c.valid() {
+		return nil, errors.New("invalid channel")
+	}
+	pgm := &PGM{
+		data:        make([][]uint8, ppm.height),
+		width:       ppm.width,
+		height:      ppm.height,
+		magicNumber: grayMagicNumber(ppm.magicNumber),
+		max:         ppm.max,
+	}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, ppm.width)
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = c.sample(ppm.data[y][x])
+		}
+	}
+	return pgm, nil
+}
+
+// SetChannel replaces one channel of the PPM image by the samples of a PGM
+// image of the same size, rescaled to the max value of the PPM image.
+func (ppm *PPM) SetChannel(c Channel, pgm *PGM) error {
+	if !c.valid() {
+		return errors.New("invalid channel")
+	}
+	if pgm.width != ppm.width || pgm.height != ppm.height {
+		return errors.New("channel size does not match the image")
+	}
+	for y := range ppm.data {
+		for x := range ppm.data[y] {
+			c.set(&ppm.data[y][x], rescale(pgm.data[y][x], pgm.max, ppm.max))
+		}
+	}
+	return nil
+}
+
+// Split separates the PPM image into its red, green and blue channels, like
+// ppmtorgb3.
+func (ppm *PPM) Split() (red, green, blue *PGM) {
+	red, _ = ppm.Channel(ChannelRed)
+	green, _ = ppm.Channel(ChannelGreen)
+	blue, _ = ppm.Channel(ChannelBlue)
+	return red, green, blue
+}
+
+// MergePPM combines three PGM images of the same size into the red, green
+// and blue channels of a PPM image, like rgb3toppm. The result uses the
+// largest max value of the three, samples of the others being rescaled, and
+// the encoding of red.
+func MergePPM(red, green, blue *PGM) (*PPM, error) {
+	if red.width != green.width || red.width != blue.width || red.height != green.height || red.height != blue.height {
+		return nil, errors.New("channels do not have the same size")
+	}
+	max := red.max
+	if green.max > max {
+		max = green.max
+	}
+	if blue.max > max {
+		max = blue.max
+	}
+	ppm := &PPM{
+		data:        make([][]Pixel, red.height),
+		width:       red.width,
+		height:      red.height,
+		magicNumber: colorMagicNumber(red.magicNumber),
+		max:         max,
+	}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, red.width)
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{
+				R: rescale(red.data[y][x], red.max, max),
+				G: rescale(green.data[y][x], green.max, max),
+				B: rescale(blue.data[y][x], blue.max, max),
+			}
+		}
+	}
+	return ppm, nil
+}
@@ -0,0 +1,65 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestSplitMerge(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{1, 2, 3}, {4, 5, 6}}}, width: 2, height: 1, magicNumber: "P6", max: 9}
+	red, green, blue := ppm.Split()
+	if red.max != 9 || red.magicNumber != "P5" {
+		t.Errorf("Split channel has max %d and magic number %s", red.max, red.magicNumber)
+	}
+	if red.data[0][1] != 4 || green.data[0][1] != 5 || blue.data[0][1] != 6 {
+		t.Errorf("Split channels are %v %v %v", red.data, green.data, blue.data)
+	}
+	merged, err := MergePPM(red, green, blue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.max != 9 || merged.magicNumber != "P6" {
+		t.Errorf("Merged image has max %d and magic number %s", merged.max, merged.magicNumber)
+	}
+	for x := range ppm.data[0] {
+		if merged.data[0][x] != ppm.data[0][x] {
+			t.Errorf("Merged pixel %d is %v, expected %v", x, merged.data[0][x], ppm.data[0][x])
+		}
+	}
+
+	blue = &PGM{data: [][]uint8{{1, 2}}, width: 2, height: 1, magicNumber: "P2", max: 1}
+	merged, err = MergePPM(red, green, blue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.data[0][0].B != 9 {
+		t.Errorf("Blue sample was not rescaled: %v", merged.data[0][0])
+	}
+	blue = &PGM{data: [][]uint8{{1}}, width: 1, height: 1, magicNumber: "P2", max: 1}
+	if _, err := MergePPM(red, green, blue); err == nil {
+		t.Error("Expected an error for mismatched sizes")
+	}
+}
+
+func TestSetChannel(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{1, 2, 3}, {4, 5, 6}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	mask := &PGM{data: [][]uint8{{0, 1}}, width: 2, height: 1, magicNumber: "P2", max: 1}
+	if err := ppm.SetChannel(ChannelGreen, mask); err != nil {
+		t.Fatal(err)
+	}
+	if ppm.data[0][0] != (Pixel{1, 0, 3}) || ppm.data[0][1] != (Pixel{4, 255, 6}) {
+		t.Errorf("Got %v", ppm.data[0])
+	}
+	green, err := ppm.Channel(ChannelGreen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if green.data[0][1] != 255 || green.magicNumber != "P2" {
+		t.Errorf("Green channel is %v", green.data)
+	}
+	if _, err := ppm.Channel(Channel(3)); err == nil {
+		t.Error("Expected an error for an invalid channel")
+	}
+	if err := ppm.SetChannel(ChannelRed, &PGM{width: 1, height: 1, data: [][]uint8{{0}}, max: 1}); err == nil {
+		t.Error("Expected an error for mismatched sizes")
+	}
+}
@@ -0,0 +1,330 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// ColorSpace identifies the meaning of the channels of Planes.
+type ColorSpace int
+
+const (
+	SpaceRGB   ColorSpace = iota // R, G, B in [0, 1]
+	SpaceHSV                     // H in degrees [0, 360), S, V in [0, 1]
+	SpaceHSL                     // H in degrees [0, 360), S, L in [0, 1]
+	SpaceXYZ                     // CIE 1931 X, Y, Z of linear sRGB under D65, Y in [0, 1]
+	SpaceLab                     // CIE L* in [0, 100], a* and b* roughly in [-128, 127], D65
+	SpaceYCbCr                   // Rec.601 full range: Y in [0, 1], Cb, Cr in [-0.5, 0.5]
+	SpaceCMYK                    // C, M, Y, K in [0, 1]
+)
+
+// channels returns the number of channels of the color space.
+func (cs ColorSpace) channels() int {
+	if cs == SpaceCMYK {
+		return 4
+	}
+	return 3
+}
+
+// Planes is an image stored as floating point channels in a color space,
+// indexed by channel, row and column.
+type Planes struct {
+	Space         ColorSpace
+	Width, Height int
+	Channels      [][][]float64
+}
+
+// D65 reference white of the XYZ and Lab conversions.
+const (
+	whiteX = 0.95047
+	whiteY = 1.0
+	whiteZ = 1.08883
+)
+
+// rgbToHue returns the hue in degrees of normalized r, g, b whose largest
+// component is max and smallest min.
+func rgbToHue(r, g, b, max, min float64) float64 {
+	d := max - min
+	if d == 0 {
+		return 0
+	}
+	var h float64
+	switch max {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// hueToRGB returns the normalized r, g, b of hue h in degrees with chroma c
+// and the given offset added to every component.
+func hueToRGB(h, c, offset float64) (r, g, b float64) {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	switch int(hp) {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + offset, g + offset, b + offset
+}
+
+// labF is the companding function of CIE L*a*b*.
+func labF(t float64) float64 {
+	if t > 216.0/24389 {
+		return math.Cbrt(t)
+	}
+	return t*24389/27/116 + 16.0/116
+}
+
+// labFInverse inverts labF.
+func labFInverse(t float64) float64 {
+	if t3 := t * t * t; t3 > 216.0/24389 {
+		return t3
+	}
+	return (116*t - 16) * 27 / 24389
+}
+
+// fromRGB converts normalized r, g, b to the color space.
+func fromRGB(cs ColorSpace, r, g, b float64) []float64 {
+	switch cs {
+	case SpaceHSV:
+		max, min := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+		var s float64
+		if max > 0 {
+			s = (max - min) / max
+		}
+		return []float64{rgbToHue(r, g, b, max, min), s, max}
+	case SpaceHSL:
+		max, min := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+		l := (max + min) / 2
+		var s float64
+		if d := max - min; d > 0 {
+			s = d / (1 - math.Abs(2*l-1))
+		}
+		return []float64{rgbToHue(r, g, b, max, min), s, l}
+	case SpaceXYZ, SpaceLab:
+		lr, lg, lb := srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)
+		x := 0.4124564*lr + 0.3575761*lg + 0.1804375*lb
+		y := 0.2126729*lr + 0.7151522*lg + 0.0721750*lb
+		z := 0.0193339*lr + 0.1191920*lg + 0.9503041*lb
+		if cs == SpaceXYZ {
+			return []float64{x, y, z}
+		}
+		fx, fy, fz := labF(x/whiteX), labF(y/whiteY), labF(z/whiteZ)
+		return []float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+	case SpaceYCbCr:
+		y := 0.299*r + 0.587*g + 0.114*b
+		return []float64{y, (b - y) / 1.772, (r - y) / 1.402}
+	case SpaceCMYK:
+		k := 1 - math.Max(r, math.Max(g, b))
+		if k >= 1 {
+			return []float64{0, 0, 0, 1}
+		}
+		return []float64{(1 - r - k) / (1 - k), (1 - g - k) / (1 - k), (1 - b - k) / (1 - k), k}
+	}
+	return []float64{r, g, b}
+}
+
+// toRGB converts the channels c of the color space to normalized r, g, b.
+func toRGB(cs ColorSpace, c []float64) (r, g, b float64) {
+	switch cs {
+	case SpaceHSV:
+		chroma := c[2] * c[1]
+		return hueToRGB(c[0], chroma, c[2]-chroma)
+	case SpaceHSL:
+		chroma := (1 - math.Abs(2*c[2]-1)) * c[1]
+		return hueToRGB(c[0], chroma, c[2]-chroma/2)
+	case SpaceXYZ, SpaceLab:
+		x, y, z := c[0], c[1], c[2]
+		if cs == SpaceLab {
+			fy := (c[0] + 16) / 116
+			x = whiteX * labFInverse(fy+c[1]/500)
+			y = whiteY * labFInverse(fy)
+			z = whiteZ * labFInverse(fy-c[2]/200)
+		}
+		lr := 3.2404542*x - 1.5371385*y - 0.4985314*z
+		lg := -0.9692660*x + 1.8760108*y + 0.0415560*z
+		lb := 0.0556434*x - 0.2040259*y + 1.0572252*z
+		return linearToSRGB(math.Max(lr, 0)), linearToSRGB(math.Max(lg, 0)), linearToSRGB(math.Max(lb, 0))
+	case SpaceYCbCr:
+		r = c[0] + 1.402*c[2]
+		b = c[0] + 1.772*c[1]
+		g = (c[0] - 0.299*r - 0.114*b) / 0.587
+		return r, g, b
+	case SpaceCMYK:
+		return (1 - c[0]) * (1 - c[3]), (1 - c[1]) * (1 - c[3]), (1 - c[2]) * (1 - c[3])
+	}
+	return c[0], c[1], c[2]
+}
+
+// ToColorSpace converts the PPM image to floating point channels in the
+// given color space. The samples are taken as sRGB.
+func (ppm *PPM) ToColorSpace(space ColorSpace) *Planes {
+	planes := &Planes{Space: space, Width: ppm.width, Height: ppm.height, Channels: make([][][]float64, space.channels())}
+	for c := range planes.Channels {
+		planes.Channels[c] = make([][]float64, ppm.height)
+		for y := range planes.Channels[c] {
+			planes.Channels[c][y] = make([]float64, ppm.width)
+		}
+	}
+	max := float64(ppm.max)
+	if max == 0 {
+		max = 1
+	}
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y][x]
+			values := fromRGB(space, float64(p.R)/max, float64(p.G)/max, float64(p.B)/max)
+			for c, v := range values {
+				planes.Channels[c][y][x] = v
+			}
+		}
+	}
+	return planes
+}
+
+// ToPPM converts the planes back to a PPM image with the given max value.
+// Colours outside of the sRGB gamut are clipped.
+func (planes *Planes) ToPPM(max uint8) (*PPM, error) {
+	if len(planes.Channels) != planes.Space.channels() {
+		return nil, errors.New("number of channels does not match the color space")
+	}
+	for _, channel := range planes.Channels {
+		if len(channel) != planes.Height {
+			return nil, errors.New("channel size does not match the planes")
+		}
+		for _, row := range channel {
+			if len(row) != planes.Width {
+				return nil, errors.New("channel size does not match the planes")
+			}
+		}
+	}
+	ppm := &PPM{
+		data:        make([][]Pixel, planes.Height),
+		width:       planes.Width,
+		height:      planes.Height,
+		magicNumber: "P3",
+		max:         max,
+	}
+	values := make([]float64, len(planes.Channels))
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, planes.Width)
+		for x := range ppm.data[y] {
+			for c := range values {
+				values[c] = planes.Channels[c][y][x]
+			}
+			r, g, b := toRGB(planes.Space, values)
+			ppm.data[y][x] = Pixel{
+				R: toSample(r*float64(max), max),
+				G: toSample(g*float64(max), max),
+				B: toSample(b*float64(max), max),
+			}
+		}
+	}
+	return ppm, nil
+}
+
+// adjustHSV applies f to the HSV channels of every pixel of the PPM image.
+func (ppm *PPM) adjustHSV(f func(hsv []float64)) {
+	max := float64(ppm.max)
+	if max == 0 {
+		return
+	}
+	for y := range ppm.data {
+		for x, p := range ppm.data[y] {
+			hsv := fromRGB(SpaceHSV, float64(p.R)/max, float64(p.G)/max, float64(p.B)/max)
+			f(hsv)
+			r, g, b := toRGB(SpaceHSV, hsv)
+			ppm.data[y][x] = Pixel{R: toSample(r*max, ppm.max), G: toSample(g*max, ppm.max), B: toSample(b*max, ppm.max)}
+		}
+	}
+}
+
+// RotateHue shifts the hue of every pixel of the PPM image by the given
+// number of degrees, keeping saturation and value.
+func (ppm *PPM) RotateHue(degrees float64) {
+	ppm.adjustHSV(func(hsv []float64) {
+		hsv[0] = math.Mod(hsv[0]+degrees, 360)
+	})
+}
+
+// Saturate multiplies the saturation of every pixel of the PPM image by
+// factor: 0 gives greys, 1 leaves the image unchanged and larger values make
+// colours more vivid.
+func (ppm *PPM) Saturate(factor float64) {
+	ppm.adjustHSV(func(hsv []float64) {
+		hsv[1] = math.Min(math.Max(hsv[1]*factor, 0), 1)
+	})
+}
+
+// WhiteBalance scales the channels of the PPM image so that the reference
+// colour, sampled from something that should be neutral, becomes a grey of
+// the same average intensity.
+func (ppm *PPM) WhiteBalance(reference Pixel) error {
+	if reference.R == 0 || reference.G == 0 || reference.B == 0 {
+		return errors.New("reference colour must have non-zero channels")
+	}
+	gray := (float64(reference.R) + float64(reference.G) + float64(reference.B)) / 3
+	kr, kg, kb := gray/float64(reference.R), gray/float64(reference.G), gray/float64(reference.B)
+	for y := range ppm.data {
+		for x, p := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{
+				R: toSample(float64(p.R)*kr, ppm.max),
+				G: toSample(float64(p.G)*kg, ppm.max),
+				B: toSample(float64(p.B)*kb, ppm.max),
+			}
+		}
+	}
+	return nil
+}
+
+// GrayWorld balances the PPM image assuming that its average colour should be
+// neutral; see WhiteBalance.
+func (ppm *PPM) GrayWorld() error {
+	var r, g, b, n float64
+	for y := range ppm.data {
+		for _, p := range ppm.data[y] {
+			r += float64(p.R)
+			g += float64(p.G)
+			b += float64(p.B)
+			n++
+		}
+	}
+	if n == 0 || r == 0 || g == 0 || b == 0 {
+		return errors.New("average colour must have non-zero channels")
+	}
+	gray := (r + g + b) / 3
+	kr, kg, kb := gray/r, gray/g, gray/b
+	for y := range ppm.data {
+		for x, p := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{
+				R: toSample(float64(p.R)*kr, ppm.max),
+				G: toSample(float64(p.G)*kg, ppm.max),
+				B: toSample(float64(p.B)*kb, ppm.max),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package Netpbm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestColorSpaceRoundTrip(t *testing.T) {
+	ppm := &PPM{
+		data:        [][]Pixel{{{255, 0, 0}, {0, 255, 0}, {0, 0, 255}, {200, 100, 50}, {0, 0, 0}, {255, 255, 255}, {12, 180, 99}}},
+		width:       7,
+		height:      1,
+		magicNumber: "P3",
+		max:         255,
+	}
+	for _, space := range []ColorSpace{SpaceRGB, SpaceHSV, SpaceHSL, SpaceXYZ, SpaceLab, SpaceYCbCr, SpaceCMYK} {
+		back, err := ppm.ToColorSpace(space).ToPPM(255)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x, p := range ppm.data[0] {
+			q := back.data[0][x]
+			if absDiff(p.R, q.R) > 1 || absDiff(p.G, q.G) > 1 || absDiff(p.B, q.B) > 1 {
+				t.Errorf("Space %d: %v became %v", space, p, q)
+			}
+		}
+	}
+}
+
+func TestToColorSpace(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{255, 0, 0}, {255, 255, 255}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	tests := []struct {
+		space    ColorSpace
+		x        int
+		expected []float64
+	}{
+		{SpaceHSV, 0, []float64{0, 1, 1}},
+		{SpaceHSL, 0, []float64{0, 1, 0.5}},
+		{SpaceCMYK, 0, []float64{0, 1, 1, 0}},
+		{SpaceYCbCr, 0, []float64{0.299, -0.299 / 1.772, 0.701 / 1.402}},
+		{SpaceLab, 0, []float64{53.24, 80.09, 67.20}},
+		{SpaceLab, 1, []float64{100, 0, 0}},
+		{SpaceXYZ, 1, []float64{0.9505, 1, 1.089}},
+	}
+	for _, test := range tests {
+		planes := ppm.ToColorSpace(test.space)
+		for c, v := range test.expected {
+			if got := planes.Channels[c][0][test.x]; math.Abs(got-v) > 0.01 {
+				t.Errorf("Space %d, pixel %d, channel %d: got %f, expected %f", test.space, test.x, c, got, v)
+			}
+		}
+	}
+	planes := ppm.ToColorSpace(SpaceHSV)
+	planes.Channels = planes.Channels[:2]
+	if _, err := planes.ToPPM(255); err == nil {
+		t.Error("Expected an error for missing channels")
+	}
+}
+
+func TestRotateHue(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{255, 0, 0}, {100, 100, 100}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	ppm.RotateHue(120)
+	if p := ppm.data[0][0]; p != (Pixel{0, 255, 0}) {
+		t.Errorf("Red rotated by 120 degrees is %v", p)
+	}
+	if p := ppm.data[0][1]; p != (Pixel{100, 100, 100}) {
+		t.Errorf("Grey changed to %v", p)
+	}
+	ppm.RotateHue(-120)
+	if p := ppm.data[0][0]; p != (Pixel{255, 0, 0}) {
+		t.Errorf("Rotating back gives %v", p)
+	}
+}
+
+func TestSaturate(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{200, 100, 100}}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	ppm.Saturate(0)
+	if p := ppm.data[0][0]; p != (Pixel{200, 200, 200}) {
+		t.Errorf("Desaturated pixel is %v", p)
+	}
+	ppm = &PPM{data: [][]Pixel{{{200, 100, 100}}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	ppm.Saturate(2)
+	if p := ppm.data[0][0]; p != (Pixel{200, 0, 0}) {
+		t.Errorf("Saturated pixel is %v", p)
+	}
+}
+
+func TestWhiteBalance(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{120, 100, 80}, {60, 50, 40}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	if err := ppm.WhiteBalance(Pixel{120, 100, 80}); err != nil {
+		t.Fatal(err)
+	}
+	if p := ppm.data[0][0]; p != (Pixel{100, 100, 100}) {
+		t.Errorf("Reference became %v", p)
+	}
+	if p := ppm.data[0][1]; p != (Pixel{50, 50, 50}) {
+		t.Errorf("Darker pixel became %v", p)
+	}
+	if err := ppm.WhiteBalance(Pixel{0, 10, 10}); err == nil {
+		t.Error("Expected an error for a zero channel")
+	}
+
+	ppm = &PPM{data: [][]Pixel{{{120, 100, 80}, {60, 50, 40}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	if err := ppm.GrayWorld(); err != nil {
+		t.Fatal(err)
+	}
+	if p := ppm.data[0][0]; p != (Pixel{100, 100, 100}) {
+		t.Errorf("Gray world gives %v", p)
+	}
+}
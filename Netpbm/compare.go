@@ -0,0 +1,300 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// msSSIMWeights are the weights of the scales of MS-SSIM, from the finest.
+var msSSIMWeights = []float64{0.0448, 0.2856, 0.3001, 0.2363, 0.1333}
+
+// scaledPlane returns a plane of data with samples rescaled from max to
+// peak.
+func scaledPlane(data [][]uint8, max uint8, peak float64) [][]float64 {
+	plane := make([][]float64, len(data))
+	for y := range data {
+		plane[y] = make([]float64, len(data[y]))
+		for x, v := range data[y] {
+			if max > 0 {
+				plane[y][x] = float64(v) * peak / float64(max)
+			}
+		}
+	}
+	return plane
+}
+
+// pairPGM returns the planes of two PGM images of the same size, rescaled to
+// the largest of their max values, which is returned as the peak.
+func pairPGM(a, b *PGM) (pa, pb [][]float64, peak float64, err error) {
+	if a.width != b.width || a.height != b.height {
+		return nil, nil, 0, errors.New("images do not have the same size")
+	}
+	if a.width*a.height == 0 {
+		return nil, nil, 0, errors.New("images are empty")
+	}
+	peak = math.Max(float64(a.max), float64(b.max))
+	return scaledPlane(a.data, a.max, peak), scaledPlane(b.data, b.max, peak), peak, nil
+}
+
+// pairPPM returns the channels of two PPM images like pairPGM.
+func pairPPM(a, b *PPM) (pa, pb [3][][]float64, peak float64, err error) {
+	if a.width != b.width || a.height != b.height {
+		return pa, pb, 0, errors.New("images do not have the same size")
+	}
+	if a.width*a.height == 0 {
+		return pa, pb, 0, errors.New("images are empty")
+	}
+	peak = math.Max(float64(a.max), float64(b.max))
+	ca, cb := channelsPPM(a), channelsPPM(b)
+	for c := range pa {
+		pa[c] = scaledPlane(ca[c], a.max, peak)
+		pb[c] = scaledPlane(cb[c], b.max, peak)
+	}
+	return pa, pb, peak, nil
+}
+
+// mse returns the mean squared difference of two planes of the same size.
+func mse(a, b [][]float64) float64 {
+	var sum float64
+	var n int
+	for y := range a {
+		for x := range a[y] {
+			d := a[y][x] - b[y][x]
+			sum += d * d
+			n++
+		}
+	}
+	return sum / float64(n)
+}
+
+// psnr returns the peak signal to noise ratio in decibels of a mean squared
+// error, +Inf for identical images.
+func psnr(mse, peak float64) float64 {
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(peak*peak/mse)
+}
+
+// ssimTerms returns the mean over a Gaussian window of σ 1.5 of the
+// luminance, contrast-structure and whole SSIM of two planes.
+func ssimTerms(a, b [][]float64, peak float64) (cs, ssim float64) {
+	c1, c2 := math.Pow(0.01*peak, 2), math.Pow(0.03*peak, 2)
+	k := GaussianKernel(1.5)
+	product := func(p, q [][]float64) [][]float64 {
+		out := make([][]float64, len(p))
+		for y := range p {
+			out[y] = make([]float64, len(p[y]))
+			for x := range p[y] {
+				out[y][x] = p[y][x] * q[y][x]
+			}
+		}
+		return out
+	}
+	muA, muB := convolvePlane(a, k, EdgeReflect), convolvePlane(b, k, EdgeReflect)
+	aa := convolvePlane(product(a, a), k, EdgeReflect)
+	bb := convolvePlane(product(b, b), k, EdgeReflect)
+	ab := convolvePlane(product(a, b), k, EdgeReflect)
+	var n float64
+	for y := range a {
+		for x := range a[y] {
+			ma, mb := muA[y][x], muB[y][x]
+			varA, varB := aa[y][x]-ma*ma, bb[y][x]-mb*mb
+			cov := ab[y][x] - ma*mb
+			contrast := (2*cov + c2) / (varA + varB + c2)
+			cs += contrast
+			ssim += (2*ma*mb + c1) / (ma*ma + mb*mb + c1) * contrast
+			n++
+		}
+	}
+	return cs / n, ssim / n
+}
+
+// msSSIM returns the multi-scale SSIM of two planes, halving them up to
+// five times. Scales that would make the planes smaller than the window are
+// dropped and the weights of the others renormalized.
+func msSSIM(a, b [][]float64, peak float64) float64 {
+	result, total := 1.0, 0.0
+	for i, w := range msSSIMWeights {
+		height, width := len(a), len(a[0])
+		last := i == len(msSSIMWeights)-1 || width/2 < 11 || height/2 < 11
+		cs, ssim := ssimTerms(a, b, peak)
+		v := cs
+		if last {
+			v = ssim
+		}
+		result *= math.Pow(math.Max(v, 0), w)
+		total += w
+		if last {
+			break
+		}
+		a = resample(a, width/2, height/2, FilterBox)
+		b = resample(b, width/2, height/2, FilterBox)
+	}
+	return math.Pow(result, 1/total)
+}
+
+// MSEPGM returns the mean squared error between two PGM images of the same
+// size. Samples are compared at the largest of the two max values.
+func MSEPGM(a, b *PGM) (float64, error) {
+	pa, pb, _, err := pairPGM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return mse(pa, pb), nil
+}
+
+// PSNRPGM returns the peak signal to noise ratio in decibels between two PGM
+// images of the same size, +Inf for identical images.
+func PSNRPGM(a, b *PGM) (float64, error) {
+	pa, pb, peak, err := pairPGM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return psnr(mse(pa, pb), peak), nil
+}
+
+// SSIMPGM returns the structural similarity of two PGM images of the same
+// size, 1 for identical images, computed over Gaussian windows of σ 1.5.
+func SSIMPGM(a, b *PGM) (float64, error) {
+	pa, pb, peak, err := pairPGM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	_, ssim := ssimTerms(pa, pb, peak)
+	return ssim, nil
+}
+
+// MSSSIMPGM returns the multi-scale structural similarity of two PGM images
+// of the same size, which compares them at up to five scales.
+func MSSSIMPGM(a, b *PGM) (float64, error) {
+	pa, pb, peak, err := pairPGM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return msSSIM(pa, pb, peak), nil
+}
+
+// MSEPPM returns the mean squared error between two PPM images of the same
+// size over all channels.
+func MSEPPM(a, b *PPM) (float64, error) {
+	pa, pb, _, err := pairPPM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return (mse(pa[0], pb[0]) + mse(pa[1], pb[1]) + mse(pa[2], pb[2])) / 3, nil
+}
+
+// PSNRPPM returns the peak signal to noise ratio in decibels between two PPM
+// images of the same size over all channels.
+func PSNRPPM(a, b *PPM) (float64, error) {
+	m, err := MSEPPM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return psnr(m, math.Max(float64(a.max), float64(b.max))), nil
+}
+
+// SSIMPPM returns the average of the structural similarities of the
+// channels of two PPM images of the same size.
+func SSIMPPM(a, b *PPM) (float64, error) {
+	pa, pb, peak, err := pairPPM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	var sum float64
+	for c := range pa {
+		_, ssim := ssimTerms(pa[c], pb[c], peak)
+		sum += ssim
+	}
+	return sum / 3, nil
+}
+
+// MSSSIMPPM returns the average of the multi-scale structural similarities
+// of the channels of two PPM images of the same size.
+func MSSSIMPPM(a, b *PPM) (float64, error) {
+	pa, pb, peak, err := pairPPM(a, b)
+	if err != nil {
+		return 0, err
+	}
+	var sum float64
+	for c := range pa {
+		sum += msSSIM(pa[c], pb[c], peak)
+	}
+	return sum / 3, nil
+}
+
+// HammingPBM returns the number of pixels that differ between two PBM images
+// of the same size.
+func HammingPBM(a, b *PBM) (int, error) {
+	if a.width != b.width || a.height != b.height {
+		return 0, errors.New("images do not have the same size")
+	}
+	var n int
+	for y := range a.data {
+		for x := range a.data[y] {
+			if a.data[y][x] != b.data[y][x] {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+// diffMap returns a PGM image of the differences, stretched so that the
+// largest becomes the max value and small differences are visible.
+func diffMap(diff [][]float64, width, height int, max uint8) *PGM {
+	var largest float64
+	for y := range diff {
+		for _, d := range diff[y] {
+			largest = math.Max(largest, d)
+		}
+	}
+	pgm := &PGM{data: make([][]uint8, height), width: width, height: height, magicNumber: "P2", max: max}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, width)
+		if largest == 0 {
+			continue
+		}
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = toSample(diff[y][x]/largest*float64(max), max)
+		}
+	}
+	return pgm
+}
+
+// DiffMapPGM returns a PGM image of the absolute differences between two PGM
+// images of the same size, stretched so that the largest difference is
+// white. Identical images give a black map.
+func DiffMapPGM(a, b *PGM) (*PGM, error) {
+	pa, pb, peak, err := pairPGM(a, b)
+	if err != nil {
+		return nil, err
+	}
+	for y := range pa {
+		for x := range pa[y] {
+			pa[y][x] = math.Abs(pa[y][x] - pb[y][x])
+		}
+	}
+	return diffMap(pa, a.width, a.height, uint8(peak)), nil
+}
+
+// DiffMapPPM returns a PGM image of the largest channel difference at each
+// pixel of two PPM images of the same size; see DiffMapPGM.
+func DiffMapPPM(a, b *PPM) (*PGM, error) {
+	pa, pb, peak, err := pairPPM(a, b)
+	if err != nil {
+		return nil, err
+	}
+	diff := pa[0]
+	for y := range diff {
+		for x := range diff[y] {
+			var d float64
+			for c := range pa {
+				d = math.Max(d, math.Abs(pa[c][y][x]-pb[c][y][x]))
+			}
+			diff[y][x] = d
+		}
+	}
+	return diffMap(diff, a.width, a.height, uint8(peak)), nil
+}
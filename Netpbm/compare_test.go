@@ -0,0 +1,115 @@
+package Netpbm
+
+import (
+	"math"
+	"testing"
+)
+
+// patternPGM returns a 64x64 PGM image of smooth stripes, plus offset on
+// every third pixel.
+func patternPGM(offset int) *PGM {
+	pgm := &PGM{data: make([][]uint8, 64), width: 64, height: 64, magicNumber: "P2", max: 255}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, 64)
+		for x := range pgm.data[y] {
+			v := 128 + 100*math.Sin(float64(x+y)/6)
+			if (x+2*y)%3 == 0 {
+				v += float64(offset)
+			}
+			pgm.data[y][x] = toSample(v, 255)
+		}
+	}
+	return pgm
+}
+
+func TestMSEPSNR(t *testing.T) {
+	a := &PGM{data: [][]uint8{{0, 10}}, width: 2, height: 1, magicNumber: "P2", max: 255}
+	b := &PGM{data: [][]uint8{{2, 10}}, width: 2, height: 1, magicNumber: "P2", max: 255}
+	mse, err := MSEPGM(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mse != 2 {
+		t.Errorf("MSE is %f", mse)
+	}
+	psnr, _ := PSNRPGM(a, b)
+	if math.Abs(psnr-10*math.Log10(255*255/2.0)) > 1e-9 {
+		t.Errorf("PSNR is %f", psnr)
+	}
+	if psnr, _ := PSNRPGM(a, a); !math.IsInf(psnr, 1) {
+		t.Errorf("PSNR of identical images is %f", psnr)
+	}
+	if _, err := MSEPGM(a, &PGM{data: [][]uint8{{0}}, width: 1, height: 1, max: 255}); err == nil {
+		t.Error("Expected an error for mismatched sizes")
+	}
+
+	pa := &PPM{data: [][]Pixel{{{0, 0, 0}}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	pb := &PPM{data: [][]Pixel{{{3, 0, 0}}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	if mse, _ := MSEPPM(pa, pb); mse != 3 {
+		t.Errorf("MSE of PPM images is %f", mse)
+	}
+}
+
+func TestSSIM(t *testing.T) {
+	a, noisy := patternPGM(0), patternPGM(40)
+	inverted := patternPGM(0)
+	inverted.Invert()
+	for _, f := range []func(a, b *PGM) (float64, error){SSIMPGM, MSSSIMPGM} {
+		same, err := f(a, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(same-1) > 1e-9 {
+			t.Errorf("Similarity of identical images is %f", same)
+		}
+		near, _ := f(a, noisy)
+		far, _ := f(a, inverted)
+		if near >= 1 || near < 0.3 || far >= near {
+			t.Errorf("Similarity is %f with noise and %f when inverted", near, far)
+		}
+	}
+
+	ppm := solidPPM(16, 16, Pixel{10, 20, 30})
+	if ssim, err := SSIMPPM(ppm, ppm); err != nil || math.Abs(ssim-1) > 1e-9 {
+		t.Errorf("SSIM of identical PPM images is %f (%v)", ssim, err)
+	}
+	if ssim, err := MSSSIMPPM(ppm, ppm); err != nil || math.Abs(ssim-1) > 1e-9 {
+		t.Errorf("MS-SSIM of identical PPM images is %f (%v)", ssim, err)
+	}
+}
+
+func TestHammingPBM(t *testing.T) {
+	n, err := HammingPBM(bitmap("##..", "...."), bitmap("#.#.", "...#"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("Hamming distance is %d", n)
+	}
+}
+
+func TestDiffMap(t *testing.T) {
+	a := &PGM{data: [][]uint8{{10, 20, 30}}, width: 3, height: 1, magicNumber: "P2", max: 255}
+	b := &PGM{data: [][]uint8{{10, 25, 20}}, width: 3, height: 1, magicNumber: "P2", max: 255}
+	diff, err := DiffMapPGM(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint8{0, 128, 255}
+	for x, v := range expected {
+		if diff.data[0][x] != v {
+			t.Errorf("Got %v, expected %v", diff.data[0], expected)
+			break
+		}
+	}
+
+	pa := &PPM{data: [][]Pixel{{{0, 0, 0}, {5, 5, 5}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	pb := &PPM{data: [][]Pixel{{{0, 0, 0}, {5, 9, 5}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	pdiff, err := DiffMapPPM(pa, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdiff.data[0][0] != 0 || pdiff.data[0][1] != 255 {
+		t.Errorf("Got %v", pdiff.data[0])
+	}
+}
@@ -0,0 +1,124 @@
+package Netpbm
+
+import (
+	"errors"
+)
+
+// Connectivity selects which neighbours of a pixel are connected to it.
+type Connectivity int
+
+const (
+	ConnectivityFour  Connectivity = iota // left, right, top and bottom neighbours
+	ConnectivityEight                     // the four above plus the diagonal neighbours
+)
+
+// neighbours returns the offsets of the neighbours of a pixel.
+func (c Connectivity) neighbours() [][2]int {
+	if c == ConnectivityEight {
+		return [][2]int{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+	}
+	return [][2]int{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+}
+
+// Component describes a group of connected black pixels of a PBM image.
+type Component struct {
+	Label               int // value of the pixels of the component in the label map
+	Area                int // number of pixels
+	X, Y, Width, Height int // bounding box
+	Centroid            Point
+	Perimeter           int // number of pixel sides between the component and white pixels or the outside
+}
+
+// label floods the black pixels connected to x, y that have no label yet
+// with the given label and returns the component they form.
+func (pbm *PBM) label(labels [][]int, x, y, label int, conn Connectivity) Component {
+	c := Component{Label: label, X: x, Y: y}
+	right, bottom := x, y
+	var sumX, sumY float64
+	labels[y][x] = label
+	stack := [][2]int{{x, y}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		px, py := p[0], p[1]
+		c.Area++
+		sumX += float64(px)
+		sumY += float64(py)
+		if px < c.X {
+			c.X = px
+		}
+		if px > right {
+			right = px
+		}
+		if py < c.Y {
+			c.Y = py
+		}
+		if py > bottom {
+			bottom = py
+		}
+		for _, d := range ConnectivityFour.neighbours() {
+			nx, ny := px+d[0], py+d[1]
+			if nx < 0 || ny < 0 || nx >= pbm.width || ny >= pbm.height || !pbm.data[ny][nx] {
+				c.Perimeter++
+			}
+		}
+		for _, d := range conn.neighbours() {
+			nx, ny := px+d[0], py+d[1]
+			if nx < 0 || ny < 0 || nx >= pbm.width || ny >= pbm.height {
+				continue
+			}
+			if pbm.data[ny][nx] && labels[ny][nx] == 0 {
+				labels[ny][nx] = label
+				stack = append(stack, [2]int{nx, ny})
+			}
+		}
+	}
+	c.Width, c.Height = right-c.X+1, bottom-c.Y+1
+	c.Centroid = Point{sumX / float64(c.Area), sumY / float64(c.Area)}
+	return c
+}
+
+// Components labels the groups of connected black pixels of the PBM image.
+// It returns a label map, where white pixels are 0 and the pixels of each
+// component have its label, and the components in the order they are met
+// scanning the image row by row. Components with fewer than minArea pixels
+// or, when maxArea is positive, more than maxArea pixels are dropped and the
+// remaining ones numbered from 1.
+func (pbm *PBM) Components(conn Connectivity, minArea, maxArea int) ([][]int, []Component, error) {
+	if conn != ConnectivityFour && conn != ConnectivityEight {
+		return nil, nil, errors.New("invalid connectivity")
+	}
+	if minArea < 0 || maxArea > 0 && maxArea < minArea {
+		return nil, nil, errors.New("invalid area range")
+	}
+	labels := make([][]int, pbm.height)
+	for y := range labels {
+		labels[y] = make([]int, pbm.width)
+	}
+	var found []Component
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y][x] && labels[y][x] == 0 {
+				found = append(found, pbm.label(labels, x, y, len(found)+1, conn))
+			}
+		}
+	}
+
+	// Renumber the kept components, 0 erasing the others
+	renumber := make([]int, len(found)+1)
+	var components []Component
+	for _, c := range found {
+		if c.Area < minArea || maxArea > 0 && c.Area > maxArea {
+			continue
+		}
+		renumber[c.Label] = len(components) + 1
+		c.Label = len(components) + 1
+		components = append(components, c)
+	}
+	for y := range labels {
+		for x, l := range labels[y] {
+			labels[y][x] = renumber[l]
+		}
+	}
+	return labels, components, nil
+}
@@ -0,0 +1,52 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestComponents(t *testing.T) {
+	pbm := bitmap(
+		"##...#",
+		"##..#.",
+		"......",
+		"...###",
+	)
+	labels, components, err := pbm.Components(ConnectivityFour, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 4 {
+		t.Fatalf("Got %d 4-connected components", len(components))
+	}
+	square := components[0]
+	if square.Label != 1 || square.Area != 4 || square.X != 0 || square.Y != 0 || square.Width != 2 || square.Height != 2 {
+		t.Errorf("Square is %+v", square)
+	}
+	if square.Centroid != (Point{0.5, 0.5}) || square.Perimeter != 8 {
+		t.Errorf("Square has centroid %v and perimeter %d", square.Centroid, square.Perimeter)
+	}
+	line := components[3]
+	if line.Area != 3 || line.Width != 3 || line.Height != 1 || line.Perimeter != 8 || line.Centroid != (Point{4, 3}) {
+		t.Errorf("Line is %+v", line)
+	}
+	if labels[1][4] != 3 || labels[3][5] != 4 || labels[2][0] != 0 {
+		t.Errorf("Label map is %v", labels)
+	}
+
+	// The diagonal pair joins with 8-connectivity
+	_, components, _ = pbm.Components(ConnectivityEight, 0, 0)
+	if len(components) != 3 || components[1].Area != 2 || components[1].Width != 2 {
+		t.Errorf("Got 8-connected components %+v", components)
+	}
+
+	labels, components, _ = pbm.Components(ConnectivityEight, 3, 3)
+	if len(components) != 1 || components[0].Label != 1 || components[0].Area != 3 {
+		t.Errorf("Filtered components are %+v", components)
+	}
+	if labels[0][0] != 0 || labels[3][3] != 1 {
+		t.Errorf("Filtered label map is %v", labels)
+	}
+	if _, _, err := pbm.Components(ConnectivityEight, 5, 2); err == nil {
+		t.Error("Expected an error for an empty area range")
+	}
+}
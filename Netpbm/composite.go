@@ -0,0 +1,153 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// CompositeOperator selects the Porter-Duff operator combining a source
+// image with a destination image.
+type CompositeOperator int
+
+const (
+	CompositeOver CompositeOperator = iota // source over destination
+	CompositeIn                            // source where the destination is opaque
+	CompositeOut                           // source where the destination is transparent
+	CompositeAtop                          // source over destination, only where the destination is opaque
+	CompositeXor                           // source and destination where the other is transparent
+)
+
+// BlendMode selects how the colours of overlapping source and destination
+// pixels are mixed.
+type BlendMode int
+
+const (
+	BlendNormal     BlendMode = iota // source colour
+	BlendMultiply                    // product, darkens
+	BlendScreen                      // inverted product of the inverses, lightens
+	BlendOverlay                     // multiply on dark destinations, screen on light ones
+	BlendDifference                  // absolute difference
+	BlendAdd                         // sum, clipped
+)
+
+// CompositeOptions configures PPM.Composite.
+type CompositeOptions struct {
+	X, Y        int // position of the top left corner of the source
+	Operator    CompositeOperator
+	Blend       BlendMode
+	SourceAlpha *PGM // opacity of the source, opaque when nil
+	Alpha       *PGM // opacity of the destination, opaque when nil, updated in place
+}
+
+// factors returns the Porter-Duff fractions of the source and destination
+// kept by the operator, given their alphas.
+func (op CompositeOperator) factors(as, ad float64) (fa, fb float64) {
+	switch op {
+	case CompositeIn:
+		return ad, 0
+	case CompositeOut:
+		return 1 - ad, 0
+	case CompositeAtop:
+		return ad, 1 - as
+	case CompositeXor:
+		return 1 - ad, 1 - as
+	}
+	return 1, 1 - as
+}
+
+// blend mixes the normalized destination colour cb with the source colour
+// cs.
+func (mode BlendMode) blend(cb, cs float64) float64 {
+	switch mode {
+	case BlendMultiply:
+		return cb * cs
+	case BlendScreen:
+		return cb + cs - cb*cs
+	case BlendOverlay:
+		if cb <= 0.5 {
+			return 2 * cb * cs
+		}
+		return 1 - 2*(1-cb)*(1-cs)
+	case BlendDifference:
+		return math.Abs(cb - cs)
+	case BlendAdd:
+		return math.Min(cb+cs, 1)
+	}
+	return cs
+}
+
+// alphaAt returns the normalized alpha of a mask at x, y, 1 for a nil mask.
+func alphaAt(mask *PGM, x, y int) float64 {
+	if mask == nil || mask.max == 0 {
+		return 1
+	}
+	return float64(mask.data[y][x]) / float64(mask.max)
+}
+
+// Composite places src over the PPM image with its top left corner at
+// opts.X, opts.Y, like pamcomp. Colours are first mixed with opts.Blend where
+// both images are opaque, then combined with opts.Operator. Only the pixels
+// covered by the source are changed. When the destination has no alpha mask
+// the alpha of the result is dropped.
+func (ppm *PPM) Composite(src *PPM, opts CompositeOptions) error {
+	if opts.SourceAlpha != nil && (opts.SourceAlpha.width != src.width || opts.SourceAlpha.height != src.height) {
+		return errors.New("source alpha size does not match the source")
+	}
+	if opts.Alpha != nil && (opts.Alpha.width != ppm.width || opts.Alpha.height != ppm.height) {
+		return errors.New("alpha size does not match the image")
+	}
+	srcMax, dstMax := float64(src.max), float64(ppm.max)
+	if srcMax == 0 {
+		srcMax = 1
+	}
+	if dstMax == 0 {
+		dstMax = 1
+	}
+	for sy := 0; sy < src.height; sy++ {
+		y := opts.Y + sy
+		if y < 0 || y >= ppm.height {
+			continue
+		}
+		for sx := 0; sx < src.width; sx++ {
+			x := opts.X + sx
+			if x < 0 || x >= ppm.width {
+				continue
+			}
+			as, ad := alphaAt(opts.SourceAlpha, sx, sy), alphaAt(opts.Alpha, x, y)
+			fa, fb := opts.Operator.factors(as, ad)
+			ao := fa*as + fb*ad
+			s, d := src.data[sy][sx], ppm.data[y][x]
+			mix := func(cs, cd uint8) uint8 {
+				if ao == 0 {
+					return 0
+				}
+				ns, nd := float64(cs)/srcMax, float64(cd)/dstMax
+				ns = (1-ad)*ns + ad*opts.Blend.blend(nd, ns)
+				return toSample((fa*as*ns+fb*ad*nd)/ao*float64(ppm.max), ppm.max)
+			}
+			ppm.data[y][x] = Pixel{R: mix(s.R, d.R), G: mix(s.G, d.G), B: mix(s.B, d.B)}
+			if opts.Alpha != nil {
+				opts.Alpha.data[y][x] = toSample(ao*float64(opts.Alpha.max), opts.Alpha.max)
+			}
+		}
+	}
+	return nil
+}
+
+// Flatten composites the PPM image, whose opacity is given by alpha, over a
+// background colour, making it opaque.
+func (ppm *PPM) Flatten(alpha *PGM, background Pixel) error {
+	if alpha.width != ppm.width || alpha.height != ppm.height {
+		return errors.New("alpha size does not match the image")
+	}
+	for y := range ppm.data {
+		for x, p := range ppm.data[y] {
+			a := alphaAt(alpha, x, y)
+			mix := func(c, b uint8) uint8 {
+				return toSample(a*float64(c)+(1-a)*float64(b), ppm.max)
+			}
+			ppm.data[y][x] = Pixel{R: mix(p.R, background.R), G: mix(p.G, background.G), B: mix(p.B, background.B)}
+		}
+	}
+	return nil
+}
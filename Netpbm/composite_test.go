@@ -0,0 +1,107 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+// solidPPM returns a width x height PPM image filled with one colour.
+func solidPPM(width, height int, p Pixel) *PPM {
+	ppm := &PPM{data: make([][]Pixel, height), width: width, height: height, magicNumber: "P3", max: 255}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, width)
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = p
+		}
+	}
+	return ppm
+}
+
+func TestCompositeOver(t *testing.T) {
+	dst := solidPPM(4, 4, Pixel{0, 0, 0})
+	src := solidPPM(2, 2, Pixel{200, 100, 50})
+	if err := dst.Composite(src, CompositeOptions{X: 3, Y: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if dst.data[0][3] != (Pixel{200, 100, 50}) || dst.data[1][3] != (Pixel{0, 0, 0}) || dst.data[0][2] != (Pixel{0, 0, 0}) {
+		t.Errorf("Got %v", dst.data)
+	}
+
+	dst = solidPPM(2, 1, Pixel{0, 0, 0})
+	alpha := &PGM{data: [][]uint8{{1, 2}}, width: 2, height: 1, magicNumber: "P2", max: 2}
+	if err := dst.Composite(solidPPM(2, 1, Pixel{200, 100, 50}), CompositeOptions{SourceAlpha: alpha}); err != nil {
+		t.Fatal(err)
+	}
+	if dst.data[0][0] != (Pixel{100, 50, 25}) || dst.data[0][1] != (Pixel{200, 100, 50}) {
+		t.Errorf("Half transparent source gives %v", dst.data[0])
+	}
+	if err := dst.Composite(src, CompositeOptions{SourceAlpha: alpha}); err == nil {
+		t.Error("Expected an error for a mismatched source alpha")
+	}
+}
+
+func TestCompositeBlend(t *testing.T) {
+	tests := []struct {
+		mode     BlendMode
+		dst, src uint8
+		expected uint8
+	}{
+		{BlendNormal, 128, 50, 50},
+		{BlendMultiply, 128, 128, 64},
+		{BlendScreen, 128, 128, 192},
+		{BlendOverlay, 51, 255, 102},
+		{BlendOverlay, 204, 0, 153},
+		{BlendDifference, 200, 50, 150},
+		{BlendAdd, 200, 100, 255},
+	}
+	for _, test := range tests {
+		dst := solidPPM(1, 1, Pixel{test.dst, test.dst, test.dst})
+		src := solidPPM(1, 1, Pixel{test.src, test.src, test.src})
+		if err := dst.Composite(src, CompositeOptions{Blend: test.mode}); err != nil {
+			t.Fatal(err)
+		}
+		if v := dst.data[0][0].R; v != test.expected {
+			t.Errorf("Mode %d of %d and %d: got %d, expected %d", test.mode, test.dst, test.src, v, test.expected)
+		}
+	}
+}
+
+func TestCompositeOperators(t *testing.T) {
+	tests := []struct {
+		op           CompositeOperator
+		srcA, dstA   uint8
+		color, alpha uint8
+	}{
+		{CompositeIn, 255, 255, 200, 255},
+		{CompositeIn, 255, 0, 0, 0},
+		{CompositeOut, 255, 0, 200, 255},
+		{CompositeOut, 255, 255, 0, 0},
+		{CompositeAtop, 128, 255, 150, 255},
+		{CompositeAtop, 255, 0, 0, 0},
+		{CompositeXor, 255, 255, 0, 0},
+		{CompositeXor, 255, 0, 200, 255},
+		{CompositeOver, 0, 255, 100, 255},
+	}
+	for _, test := range tests {
+		dst := solidPPM(1, 1, Pixel{100, 100, 100})
+		src := solidPPM(1, 1, Pixel{200, 200, 200})
+		srcAlpha := &PGM{data: [][]uint8{{test.srcA}}, width: 1, height: 1, magicNumber: "P2", max: 255}
+		dstAlpha := &PGM{data: [][]uint8{{test.dstA}}, width: 1, height: 1, magicNumber: "P2", max: 255}
+		if err := dst.Composite(src, CompositeOptions{Operator: test.op, SourceAlpha: srcAlpha, Alpha: dstAlpha}); err != nil {
+			t.Fatal(err)
+		}
+		if dst.data[0][0].R != test.color || dstAlpha.data[0][0] != test.alpha {
+			t.Errorf("Operator %d with alphas %d, %d: got colour %d and alpha %d", test.op, test.srcA, test.dstA, dst.data[0][0].R, dstAlpha.data[0][0])
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{200, 200, 200}, {200, 0, 0}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	alpha := &PGM{data: [][]uint8{{0, 1}}, width: 2, height: 1, magicNumber: "P2", max: 1}
+	if err := ppm.Flatten(alpha, Pixel{0, 0, 255}); err != nil {
+		t.Fatal(err)
+	}
+	if ppm.data[0][0] != (Pixel{0, 0, 255}) || ppm.data[0][1] != (Pixel{200, 0, 0}) {
+		t.Errorf("Got %v", ppm.data[0])
+	}
+}
@@ -0,0 +1,277 @@
+package Netpbm
+
+import (
+	"math"
+	"sort"
+)
+
+// Contour is a boundary of a group of black pixels of a PBM image, given as
+// the coordinates of its boundary pixels in order.
+type Contour struct {
+	Points []Point
+	Hole   bool // the contour surrounds a white hole instead of a black group
+	Parent int  // index of the contour directly enclosing this one, -1 for none
+}
+
+// contourDirections lists the offsets of the 8 neighbours of a pixel,
+// clockwise on screen starting from the right one.
+var contourDirections = [8][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+// contourDirection returns the index in contourDirections of an offset.
+func contourDirection(dx, dy int) int {
+	for i, d := range contourDirections {
+		if d[0] == dx && d[1] == dy {
+			return i
+		}
+	}
+	return 0
+}
+
+// Contours traces the boundaries of the black pixels of the PBM image with
+// the border following algorithm of Suzuki and Abe. Black pixels are
+// 8-connected and white ones 4-connected. Outer contours go around groups of
+// black pixels and hole contours around the white holes inside them; the
+// Parent of each contour gives the resulting hierarchy. Outer contours turn
+// counterclockwise on screen and hole contours clockwise. Contours are listed
+// in the order their first pixel is met scanning the image row by row.
+func (pbm *PBM) Contours() []Contour {
+	// Work on a copy with a white frame, where the borders found are marked
+	// with their number, negative on their right side
+	width, height := pbm.width+2, pbm.height+2
+	f := make([][]int, height)
+	for y := range f {
+		f[y] = make([]int, width)
+	}
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y][x] {
+				f[y+1][x+1] = 1
+			}
+		}
+	}
+
+	// Border 1 is the frame, a hole without parent
+	var contours []Contour
+	hole := map[int]bool{1: true}
+	parent := map[int]int{1: -1}
+	border := 1
+	at := func(x, y int) int { return f[y][x] }
+
+	for y := 1; y < height-1; y++ {
+		last := 1
+		for x := 1; x < width-1; x++ {
+			var fromX, fromY int
+			isHole := false
+			switch {
+			case f[y][x] == 1 && f[y][x-1] == 0:
+				fromX, fromY = x-1, y
+			case f[y][x] >= 1 && f[y][x+1] == 0:
+				fromX, fromY = x+1, y
+				isHole = true
+				if f[y][x] > 1 {
+					last = f[y][x]
+				}
+			default:
+				if f[y][x] != 0 && f[y][x] != 1 {
+					last = abs(f[y][x])
+				}
+				continue
+			}
+
+			border++
+			hole[border] = isHole
+			if isHole == hole[last] {
+				parent[border] = parent[last]
+			} else {
+				parent[border] = last
+			}
+			contour := Contour{Hole: isHole, Parent: parent[border] - 2}
+			if parent[border] < 2 {
+				contour.Parent = -1
+			}
+
+			// Look clockwise for the first neighbour of the border
+			start := contourDirection(fromX-x, fromY-y)
+			found := -1
+			for k := 0; k < 8; k++ {
+				d := contourDirections[(start+k)%8]
+				if at(x+d[0], y+d[1]) != 0 {
+					found = (start + k) % 8
+					break
+				}
+			}
+			if found < 0 {
+				// Isolated pixel
+				f[y][x] = -border
+				contour.Points = []Point{{float64(x - 1), float64(y - 1)}}
+				contours = append(contours, contour)
+				last = border
+				continue
+			}
+
+			firstX, firstY := x+contourDirections[found][0], y+contourDirections[found][1]
+			prevX, prevY := firstX, firstY
+			curX, curY := x, y
+			for {
+				contour.Points = append(contour.Points, Point{float64(curX - 1), float64(curY - 1)})
+				// Look counterclockwise for the next pixel, starting after the
+				// previous one
+				s := contourDirection(prevX-curX, prevY-curY)
+				var nextX, nextY int
+				rightIsWhite := false
+				for k := 1; k <= 8; k++ {
+					i := ((s-k)%8 + 8) % 8
+					d := contourDirections[i]
+					if at(curX+d[0], curY+d[1]) != 0 {
+						nextX, nextY = curX+d[0], curY+d[1]
+						break
+					}
+					if i == 0 {
+						rightIsWhite = true
+					}
+				}
+				if rightIsWhite {
+					f[curY][curX] = -border
+				} else if f[curY][curX] == 1 {
+					f[curY][curX] = border
+				}
+				if nextX == x && nextY == y && curX == firstX && curY == firstY {
+					break
+				}
+				prevX, prevY = curX, curY
+				curX, curY = nextX, nextY
+			}
+			contours = append(contours, contour)
+			if f[y][x] != 1 {
+				last = abs(f[y][x])
+			}
+		}
+	}
+	return contours
+}
+
+// abs returns the absolute value of v.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// segmentDistance returns the distance from p to the segment from a to b.
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/length))
+	return math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy)
+}
+
+// douglasPeucker returns the points of the open polyline to keep, its ends
+// included.
+func douglasPeucker(points []Point, epsilon float64) []Point {
+	if len(points) < 3 {
+		return append([]Point(nil), points...)
+	}
+	last := len(points) - 1
+	farthest, distance := 0, -1.0
+	for i := 1; i < last; i++ {
+		if d := segmentDistance(points[i], points[0], points[last]); d > distance {
+			farthest, distance = i, d
+		}
+	}
+	if distance <= epsilon {
+		return []Point{points[0], points[last]}
+	}
+	left := douglasPeucker(points[:farthest+1], epsilon)
+	right := douglasPeucker(points[farthest:], epsilon)
+	return append(left[:len(left)-1], right...)
+}
+
+// SimplifyPolygon removes the points of a polyline that lie closer than
+// epsilon to the simplified shape, with the Douglas-Peucker algorithm. When
+// closed is set the last point is connected to the first and the polygon is
+// split at the point farthest from the first before simplification.
+func SimplifyPolygon(points []Point, epsilon float64, closed bool) []Point {
+	if !closed || len(points) < 3 {
+		return douglasPeucker(points, epsilon)
+	}
+	farthest, distance := 0, -1.0
+	for i, p := range points {
+		if d := math.Hypot(p.X-points[0].X, p.Y-points[0].Y); d > distance {
+			farthest, distance = i, d
+		}
+	}
+	ring := append(append([]Point(nil), points...), points[0])
+	first := douglasPeucker(ring[:farthest+1], epsilon)
+	second := douglasPeucker(ring[farthest:], epsilon)
+	return append(first[:len(first)-1], second[:len(second)-1]...)
+}
+
+// cross returns the cross product of the vectors from o to a and from o to
+// b, positive when o, a, b turn clockwise on screen.
+func cross(o, a, b Point) float64 {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+// ConvexHull returns the vertices of the smallest convex polygon containing
+// the points, clockwise on screen starting from the leftmost, topmost point.
+// Points on the edges of the hull are left out.
+func ConvexHull(points []Point) []Point {
+	sorted := append([]Point(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+	unique := sorted[:0]
+	for _, p := range sorted {
+		if len(unique) == 0 || p != unique[len(unique)-1] {
+			unique = append(unique, p)
+		}
+	}
+	if len(unique) < 3 {
+		return unique
+	}
+	// Monotone chain: upper half on screen from left to right, then lower
+	// half back
+	hull := make([]Point, 0, 2*len(unique))
+	for _, p := range unique {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(unique) - 2; i >= 0; i-- {
+		p := unique[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// PolygonArea returns the area enclosed by a closed polygon.
+func PolygonArea(points []Point) float64 {
+	var sum float64
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		sum += p.X*q.Y - q.X*p.Y
+	}
+	return math.Abs(sum) / 2
+}
+
+// PolygonPerimeter returns the length of a closed polygon.
+func PolygonPerimeter(points []Point) float64 {
+	var sum float64
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		sum += math.Hypot(q.X-p.X, q.Y-p.Y)
+	}
+	return sum
+}
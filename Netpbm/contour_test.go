@@ -0,0 +1,73 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+// comparePoints reports an error if points differ from expected.
+func comparePoints(t *testing.T, name string, points, expected []Point) {
+	t.Helper()
+	if len(points) != len(expected) {
+		t.Errorf("%s: got %v, expected %v", name, points, expected)
+		return
+	}
+	for i := range expected {
+		if points[i] != expected[i] {
+			t.Errorf("%s: got %v, expected %v", name, points, expected)
+			return
+		}
+	}
+}
+
+func TestContours(t *testing.T) {
+	pbm := bitmap(
+		"###....",
+		"#.#..#.",
+		"###....",
+	)
+	contours := pbm.Contours()
+	if len(contours) != 3 {
+		t.Fatalf("Got %d contours", len(contours))
+	}
+	outer, hole, dot := contours[0], contours[1], contours[2]
+	if outer.Hole || outer.Parent != -1 || !hole.Hole || hole.Parent != 0 || dot.Hole || dot.Parent != -1 {
+		t.Errorf("Hierarchy is %+v", contours)
+	}
+	comparePoints(t, "Outer", outer.Points, []Point{{0, 0}, {0, 1}, {0, 2}, {1, 2}, {2, 2}, {2, 1}, {2, 0}, {1, 0}})
+	comparePoints(t, "Hole", hole.Points, []Point{{0, 1}, {1, 0}, {2, 1}, {1, 2}})
+	comparePoints(t, "Dot", dot.Points, []Point{{5, 1}})
+
+	// An object inside a hole has the hole as parent
+	nested := bitmap(
+		"#####",
+		"#...#",
+		"#.#.#",
+		"#...#",
+		"#####",
+	).Contours()
+	if len(nested) != 3 || nested[2].Parent != 1 || nested[2].Hole {
+		t.Errorf("Nested hierarchy is %+v", nested)
+	}
+}
+
+func TestSimplifyPolygon(t *testing.T) {
+	outer := bitmap("#####", "#####", "#####", "#####").Contours()[0]
+	simplified := SimplifyPolygon(outer.Points, 0.5, true)
+	comparePoints(t, "Rectangle", simplified, []Point{{0, 0}, {0, 3}, {4, 3}, {4, 0}})
+
+	line := []Point{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {4, 6}, {5, 7}}
+	comparePoints(t, "Open line", SimplifyPolygon(line, 0.5, false), []Point{{0, 0}, {2, -0.1}, {3, 5}, {5, 7}})
+}
+
+func TestConvexHull(t *testing.T) {
+	points := []Point{{0, 0}, {1, 1}, {2, 0}, {2, 2}, {0, 2}, {1, 0}, {0, 0}, {1, 2}}
+	hull := ConvexHull(points)
+	comparePoints(t, "Hull", hull, []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}})
+	if a := PolygonArea(hull); a != 4 {
+		t.Errorf("Area of the hull is %f", a)
+	}
+	if p := PolygonPerimeter(hull); p != 8 {
+		t.Errorf("Perimeter of the hull is %f", p)
+	}
+	comparePoints(t, "Two points", ConvexHull([]Point{{1, 1}, {0, 0}, {1, 1}}), []Point{{0, 0}, {1, 1}})
+}
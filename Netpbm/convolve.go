@@ -0,0 +1,236 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// Kernel is a convolution matrix of odd width and height. It is applied as
+// laid out, centred on each pixel, like pnmconvol does. A kernel cannot be
+// changed once created.
+type Kernel struct {
+	width, height int
+	data          []float64 // height rows of width weights
+
+	// Set when the matrix is the outer product of column and row, which
+	// allows convolving in two one-dimensional passes.
+	row, column []float64
+}
+
+// NewKernel returns a width x height kernel with the given weights, listed
+// row by row. The weights are copied.
+func NewKernel(width, height int, data []float64) (*Kernel, error) {
+	if width <= 0 || height <= 0 || width%2 == 0 || height%2 == 0 {
+		return nil, errors.New("kernel dimensions must be odd and positive")
+	}
+	if len(data) != width*height {
+		return nil, errors.New("kernel data does not match its dimensions")
+	}
+	return &Kernel{width: width, height: height, data: append([]float64(nil), data...)}, nil
+}
+
+// SeparableKernel returns the kernel whose weights are column[y] * row[x].
+// Both slices must have an odd length; they are copied.
+func SeparableKernel(row, column []float64) (*Kernel, error) {
+	k, err := NewKernel(len(row), len(column), make([]float64, len(row)*len(column)))
+	if err != nil {
+		return nil, err
+	}
+	for y, cy := range column {
+		for x, rx := range row {
+			k.data[y*k.width+x] = cy * rx
+		}
+	}
+	k.row, k.column = append([]float64(nil), row...), append([]float64(nil), column...)
+	return k, nil
+}
+
+// Size returns the width and height of the kernel.
+func (k *Kernel) Size() (int, int) {
+	return k.width, k.height
+}
+
+// At returns the weight at column x and row y of the kernel.
+func (k *Kernel) At(x, y int) float64 {
+	return k.data[y*k.width+x]
+}
+
+// BoxKernel returns the (2*radius+1) square kernel averaging its neighbourhood.
+// A negative radius is taken as zero.
+func BoxKernel(radius int) *Kernel {
+	if radius < 0 {
+		radius = 0
+	}
+	line := make([]float64, 2*radius+1)
+	for i := range line {
+		line[i] = 1 / float64(len(line))
+	}
+	k, _ := SeparableKernel(line, line)
+	return k
+}
+
+// GaussianKernel returns a normalized Gaussian kernel of standard deviation
+// sigma, truncated at three sigmas. A sigma that is not positive gives the
+// identity kernel.
+func GaussianKernel(sigma float64) *Kernel {
+	line := gaussianLine(sigma)
+	k, _ := SeparableKernel(line, line)
+	return k
+}
+
+// gaussianLine returns the normalized one-dimensional Gaussian of standard
+// deviation sigma, truncated at three sigmas.
+func gaussianLine(sigma float64) []float64 {
+	if !(sigma > 0) {
+		return []float64{1}
+	}
+	radius := int(math.Ceil(3 * sigma))
+	if radius < 1 {
+		radius = 1
+	}
+	line := make([]float64, 2*radius+1)
+	var sum float64
+	for i := range line {
+		d := float64(i - radius)
+		line[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += line[i]
+	}
+	for i := range line {
+		line[i] /= sum
+	}
+	return line
+}
+
+// SharpenKernel returns a 3x3 kernel enhancing edges, with weights summing to one.
+func SharpenKernel() *Kernel {
+	k, _ := NewKernel(3, 3, []float64{
+		0, -1, 0,
+		-1, 5, -1,
+		0, -1, 0,
+	})
+	return k
+}
+
+// EmbossKernel returns a 3x3 kernel giving a relief effect lit from the top left.
+func EmbossKernel() *Kernel {
+	k, _ := NewKernel(3, 3, []float64{
+		-2, -1, 0,
+		-1, 1, 1,
+		0, 1, 2,
+	})
+	return k
+}
+
+// LaplacianKernel returns the 3x3 Laplacian, whose weights sum to zero. It
+// is usually applied with a bias of half the max value.
+func LaplacianKernel() *Kernel {
+	k, _ := NewKernel(3, 3, []float64{
+		0, 1, 0,
+		1, -4, 1,
+		0, 1, 0,
+	})
+	return k
+}
+
+// Sum returns the sum of the weights of the kernel.
+func (k *Kernel) Sum() float64 {
+	var sum float64
+	for _, w := range k.data {
+		sum += w
+	}
+	return sum
+}
+
+// convolvePlane applies k to plane. Pixels outside of the plane are read
+// according to edge, EdgeConstant reading zeros.
+func convolvePlane(plane [][]float64, k *Kernel, edge EdgeMode) [][]float64 {
+	if k.row != nil {
+		return convolveLine(convolveLine(plane, k.row, edge, true), k.column, edge, false)
+	}
+	get := edgeSampler(plane, edge, 0)
+	rx, ry := k.width/2, k.height/2
+	out := make([][]float64, len(plane))
+	for y := range plane {
+		out[y] = make([]float64, len(plane[y]))
+		for x := range plane[y] {
+			var v float64
+			for j := 0; j < k.height; j++ {
+				for i := 0; i < k.width; i++ {
+					if w := k.data[j*k.width+i]; w != 0 {
+						v += w * get(x+i-rx, y+j-ry)
+					}
+				}
+			}
+			out[y][x] = v
+		}
+	}
+	return out
+}
+
+// convolveLine applies a one-dimensional kernel along the rows of plane, or
+// along its columns if horizontal is false.
+func convolveLine(plane [][]float64, line []float64, edge EdgeMode, horizontal bool) [][]float64 {
+	get := edgeSampler(plane, edge, 0)
+	r := len(line) / 2
+	out := make([][]float64, len(plane))
+	for y := range plane {
+		out[y] = make([]float64, len(plane[y]))
+		for x := range plane[y] {
+			var v float64
+			for i, w := range line {
+				if horizontal {
+					v += w * get(x+i-r, y)
+				} else {
+					v += w * get(x, y+i-r)
+				}
+			}
+			out[y][x] = v
+		}
+	}
+	return out
+}
+
+// convolutionScale returns the factor applied to convolution results.
+func convolutionScale(k *Kernel, normalize bool) float64 {
+	if sum := k.Sum(); normalize && sum != 0 {
+		return 1 / sum
+	}
+	return 1
+}
+
+// Convolve applies the kernel k to the PGM image. Pixels outside of the image
+// are read according to edge, EdgeConstant reading zeros. If normalize is
+// true the result is divided by the sum of the weights, when it is not zero.
+// bias is then added and the result clamped to the max value.
+func (pgm *PGM) Convolve(k *Kernel, edge EdgeMode, normalize bool, bias float64) {
+	scale := convolutionScale(k, normalize)
+	out := convolvePlane(planePGM(pgm), k, edge)
+	for y := range pgm.data {
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = toSample(out[y][x]*scale+bias, pgm.max)
+		}
+	}
+}
+
+// Convolve applies the kernel k to each channel of the PPM image. Pixels
+// outside of the image are read according to edge, EdgeConstant reading
+// zeros. If normalize is true the result is divided by the sum of the
+// weights, when it is not zero. bias is then added and the result clamped to
+// the max value.
+func (ppm *PPM) Convolve(k *Kernel, edge EdgeMode, normalize bool, bias float64) {
+	scale := convolutionScale(k, normalize)
+	planes := planesPPM(ppm)
+	var out [3][][]float64
+	for c := range planes {
+		out[c] = convolvePlane(planes[c], k, edge)
+	}
+	for y := range ppm.data {
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{
+				R: toSample(out[0][y][x]*scale+bias, ppm.max),
+				G: toSample(out[1][y][x]*scale+bias, ppm.max),
+				B: toSample(out[2][y][x]*scale+bias, ppm.max),
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package Netpbm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvolveBoxPGM(t *testing.T) {
+	pgm := &PGM{
+		data:        [][]uint8{{0, 0, 0}, {0, 9, 0}, {0, 0, 0}},
+		width:       3,
+		height:      3,
+		magicNumber: "P2",
+		max:         9,
+	}
+	pgm.Convolve(BoxKernel(1), EdgeConstant, false, 0)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			if pgm.data[y][x] != 1 {
+				t.Errorf("Pixel at (%d, %d) is %d, expected 1", x, y, pgm.data[y][x])
+			}
+		}
+	}
+}
+
+func TestConvolveSeparableMatchesFull(t *testing.T) {
+	pgm, err := ReadPGM("./testImages/pgm/testP2.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	separable := GaussianKernel(1)
+	full, err := NewKernel(separable.width, separable.height, separable.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := convolvePlane(planePGM(pgm), separable, EdgeReflect)
+	b := convolvePlane(planePGM(pgm), full, EdgeReflect)
+	for y := range a {
+		for x := range a[y] {
+			if math.Abs(a[y][x]-b[y][x]) > 1e-9 {
+				t.Fatalf("Pixel at (%d, %d): %f != %f", x, y, a[y][x], b[y][x])
+			}
+		}
+	}
+	if math.Abs(separable.Sum()-1) > 1e-9 {
+		t.Errorf("Gaussian kernel sums to %f", separable.Sum())
+	}
+}
+
+func TestConvolveLaplacianPPM(t *testing.T) {
+	gray := Pixel{100, 100, 100}
+	ppm := &PPM{
+		data:        [][]Pixel{{gray, gray}, {gray, gray}},
+		width:       2,
+		height:      2,
+		magicNumber: "P3",
+		max:         255,
+	}
+	ppm.Convolve(LaplacianKernel(), EdgeClamp, true, 128)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			if ppm.At(x, y) != (Pixel{128, 128, 128}) {
+				t.Errorf("Pixel at (%d, %d) is %v", x, y, ppm.At(x, y))
+			}
+		}
+	}
+	if _, err := NewKernel(2, 3, make([]float64, 6)); err == nil {
+		t.Error("Expected an error for an even width")
+	}
+}
+
+func TestKernelDegenerate(t *testing.T) {
+	for _, k := range []*Kernel{GaussianKernel(0), GaussianKernel(-1), BoxKernel(-1)} {
+		if width, height := k.Size(); width != 1 || height != 1 || k.At(0, 0) != 1 {
+			t.Errorf("Got a %dx%d kernel %v, expected the identity", width, height, k.data)
+		}
+	}
+}
+
+func TestKernelCopiesWeights(t *testing.T) {
+	data := []float64{0, 0, 1}
+	line := []float64{1, 2, 1}
+	k, _ := NewKernel(3, 1, data)
+	separable, _ := SeparableKernel(line, line)
+	data[2], line[0] = 5, 5
+	if k.At(2, 0) != 1 || separable.At(0, 0) != 1 || separable.row[0] != 1 {
+		t.Error("Kernels changed with the slices they were created from")
+	}
+}
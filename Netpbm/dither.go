@@ -0,0 +1,323 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// DitherMethod selects how tones are simulated when reducing the number of
+// levels of an image.
+type DitherMethod int
+
+const (
+	DitherFloydSteinberg    DitherMethod = iota // error diffusion over 4 neighbours
+	DitherJarvisJudiceNinke                     // error diffusion over 12 neighbours
+	DitherStucki                                // error diffusion over 12 neighbours, sharper
+	DitherAtkinson                              // error diffusion of 3/4 of the error, high contrast
+	DitherBayer2                                // ordered, 2x2 Bayer matrix
+	DitherBayer4                                // ordered, 4x4 Bayer matrix
+	DitherBayer8                                // ordered, 8x8 Bayer matrix
+	DitherBayer16                               // ordered, 16x16 Bayer matrix
+	DitherClusterDot                            // ordered, 4x4 clustered dots like halftone screens
+	DitherBlueNoise                             // ordered, 16x16 void-and-cluster matrix
+)
+
+// diffusion is one share of the quantization error pushed to a neighbour.
+type diffusion struct {
+	dx, dy int
+	weight float64
+}
+
+// diffusions returns the error diffusion kernel of the method, or nil for
+// ordered methods.
+func (m DitherMethod) diffusions() []diffusion {
+	switch m {
+	case DitherFloydSteinberg:
+		return []diffusion{{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16}}
+	case DitherJarvisJudiceNinke:
+		return []diffusion{
+			{1, 0, 7.0 / 48}, {2, 0, 5.0 / 48},
+			{-2, 1, 3.0 / 48}, {-1, 1, 5.0 / 48}, {0, 1, 7.0 / 48}, {1, 1, 5.0 / 48}, {2, 1, 3.0 / 48},
+			{-2, 2, 1.0 / 48}, {-1, 2, 3.0 / 48}, {0, 2, 5.0 / 48}, {1, 2, 3.0 / 48}, {2, 2, 1.0 / 48},
+		}
+	case DitherStucki:
+		return []diffusion{
+			{1, 0, 8.0 / 42}, {2, 0, 4.0 / 42},
+			{-2, 1, 2.0 / 42}, {-1, 1, 4.0 / 42}, {0, 1, 8.0 / 42}, {1, 1, 4.0 / 42}, {2, 1, 2.0 / 42},
+			{-2, 2, 1.0 / 42}, {-1, 2, 2.0 / 42}, {0, 2, 4.0 / 42}, {1, 2, 2.0 / 42}, {2, 2, 1.0 / 42},
+		}
+	case DitherAtkinson:
+		return []diffusion{{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8}}
+	}
+	return nil
+}
+
+// thresholdMatrix returns the ordered dithering matrix of the method, its
+// entries being the ranks 0 to n*n-1.
+func (m DitherMethod) thresholdMatrix() [][]int {
+	switch m {
+	case DitherBayer2:
+		return bayerMatrix(2)
+	case DitherBayer4:
+		return bayerMatrix(4)
+	case DitherBayer8:
+		return bayerMatrix(8)
+	case DitherBayer16:
+		return bayerMatrix(16)
+	case DitherClusterDot:
+		return [][]int{
+			{12, 5, 6, 13},
+			{4, 0, 1, 7},
+			{11, 3, 2, 8},
+			{15, 10, 9, 14},
+		}
+	case DitherBlueNoise:
+		blueNoiseOnce.Do(func() { blueNoise = voidAndCluster(16, 1.5) })
+		return blueNoise
+	}
+	return nil
+}
+
+// bayerMatrix returns the n x n Bayer matrix, n being a power of two.
+func bayerMatrix(n int) [][]int {
+	matrix := [][]int{{0}}
+	for size := 1; size < n; size *= 2 {
+		next := make([][]int, 2*size)
+		for y := range next {
+			next[y] = make([]int, 2*size)
+			for x := range next[y] {
+				v := 4 * matrix[y%size][x%size]
+				switch {
+				case y < size && x >= size:
+					v += 2
+				case y >= size && x < size:
+					v += 3
+				case y >= size && x >= size:
+					v++
+				}
+				next[y][x] = v
+			}
+		}
+		matrix = next
+	}
+	return matrix
+}
+
+var (
+	blueNoise     [][]int
+	blueNoiseOnce sync.Once
+)
+
+// voidAndCluster builds an n x n blue noise threshold matrix with Ulichney's
+// void-and-cluster algorithm, using a toroidal Gaussian of deviation sigma to
+// measure how crowded each cell is.
+func voidAndCluster(n int, sigma float64) [][]int {
+	size := n * n
+	weight := make([]float64, size)
+	for dy := 0; dy < n; dy++ {
+		for dx := 0; dx < n; dx++ {
+			wx, wy := math.Min(float64(dx), float64(n-dx)), math.Min(float64(dy), float64(n-dy))
+			weight[dy*n+dx] = math.Exp(-(wx*wx + wy*wy) / (2 * sigma * sigma))
+		}
+	}
+	pattern := make([]bool, size)
+	energy := make([]float64, size)
+	toggle := func(p int, set bool) {
+		pattern[p] = set
+		px, py := p%n, p/n
+		sign := 1.0
+		if !set {
+			sign = -1
+		}
+		for q := range energy {
+			dx, dy := (q%n-px+n)%n, (q/n-py+n)%n
+			energy[q] += sign * weight[dy*n+dx]
+		}
+	}
+	// Among the cells whose state is set, the most (or least) crowded one
+	extreme := func(set, most bool) int {
+		best := -1
+		for p := range pattern {
+			if pattern[p] != set {
+				continue
+			}
+			if best < 0 || most && energy[p] > energy[best] || !most && energy[p] < energy[best] {
+				best = p
+			}
+		}
+		return best
+	}
+
+	// Initial pattern: a tenth of the cells, spread out by swapping the
+	// tightest cluster into the largest void until it no longer moves
+	r := rand.New(rand.NewSource(1))
+	ones := size / 10
+	for _, p := range r.Perm(size)[:ones] {
+		toggle(p, true)
+	}
+	for i := 0; i < size; i++ {
+		cluster := extreme(true, true)
+		toggle(cluster, false)
+		void := extreme(false, false)
+		toggle(void, true)
+		if void == cluster {
+			break
+		}
+	}
+	initial := append([]bool(nil), pattern...)
+	initialEnergy := append([]float64(nil), energy...)
+
+	rank := make([]int, size)
+	// Remove the tightest clusters of the initial pattern, highest ranks first
+	for i := ones - 1; i >= 0; i-- {
+		p := extreme(true, true)
+		toggle(p, false)
+		rank[p] = i
+	}
+	// Fill the largest voids for the remaining ranks
+	copy(pattern, initial)
+	copy(energy, initialEnergy)
+	for i := ones; i < size; i++ {
+		p := extreme(false, false)
+		toggle(p, true)
+		rank[p] = i
+	}
+
+	matrix := make([][]int, n)
+	for y := range matrix {
+		matrix[y] = rank[y*n : (y+1)*n]
+	}
+	return matrix
+}
+
+// ditherPlane quantizes samples in [0, max] to levels evenly spaced levels
+// and returns the index of the level chosen for each pixel.
+func ditherPlane(plane [][]float64, max uint8, levels int, method DitherMethod, serpentine bool) [][]int {
+	height := len(plane)
+	out := make([][]int, height)
+	step := float64(max) / float64(levels-1)
+	quantize := func(v float64) int {
+		l := int(math.Round(v / step))
+		if l < 0 {
+			return 0
+		}
+		if l >= levels {
+			return levels - 1
+		}
+		return l
+	}
+
+	if matrix := method.thresholdMatrix(); matrix != nil {
+		n := len(matrix)
+		for y := range plane {
+			out[y] = make([]int, len(plane[y]))
+			for x, v := range plane[y] {
+				t := (float64(matrix[y%n][x%n]) + 0.5) / float64(n*n)
+				s := v / step
+				l := int(math.Floor(s))
+				if s-float64(l) > t {
+					l++
+				}
+				if l < 0 {
+					l = 0
+				} else if l >= levels {
+					l = levels - 1
+				}
+				out[y][x] = l
+			}
+		}
+		return out
+	}
+
+	// Error diffusion works on a copy since errors are added to the samples
+	work := make([][]float64, height)
+	for y := range plane {
+		work[y] = append([]float64(nil), plane[y]...)
+		out[y] = make([]int, len(plane[y]))
+	}
+	kernel := method.diffusions()
+	for y := range work {
+		width := len(work[y])
+		reverse := serpentine && y%2 == 1
+		for i := 0; i < width; i++ {
+			x, dir := i, 1
+			if reverse {
+				x, dir = width-1-i, -1
+			}
+			l := quantize(work[y][x])
+			out[y][x] = l
+			err := work[y][x] - float64(l)*step
+			for _, d := range kernel {
+				nx, ny := x+d.dx*dir, y+d.dy
+				if ny < height && nx >= 0 && nx < width {
+					work[ny][nx] += err * d.weight
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Dither converts the PGM image to PBM, simulating grey tones with patterns
+// of black and white pixels. serpentine alternates the scanning direction of
+// error diffusion methods on every row, which avoids directional artifacts.
+func (pgm *PGM) Dither(method DitherMethod, serpentine bool) *PBM {
+	levels := ditherPlane(planePGM(pgm), pgm.max, 2, method, serpentine)
+	pbm := &PBM{
+		magicNumber: "P1",
+		width:       pgm.width,
+		height:      pgm.height,
+		data:        make([][]bool, pgm.height),
+	}
+	for y := range pbm.data {
+		pbm.data[y] = make([]bool, pgm.width)
+		for x := range pbm.data[y] {
+			pbm.data[y][x] = levels[y][x] == 0
+		}
+	}
+	return pbm
+}
+
+// DitherMaxValue reduces the PGM image to samples in [0, maxValue],
+// dithering so that the average tone of each area is kept.
+func (pgm *PGM) DitherMaxValue(maxValue uint8, method DitherMethod, serpentine bool) error {
+	if maxValue == 0 {
+		return errors.New("max value must be positive")
+	}
+	levels := ditherPlane(planePGM(pgm), pgm.max, int(maxValue)+1, method, serpentine)
+	for y := range pgm.data {
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = uint8(levels[y][x])
+		}
+	}
+	pgm.max = maxValue
+	return nil
+}
+
+// Dither converts the PPM image to PBM by dithering its ToPGM conversion;
+// see PGM.Dither.
+func (ppm *PPM) Dither(method DitherMethod, serpentine bool) *PBM {
+	return ppm.ToPGM().Dither(method, serpentine)
+}
+
+// DitherMaxValue reduces the PPM image to samples in [0, maxValue],
+// dithering each channel so that the average colour of each area is kept.
+func (ppm *PPM) DitherMaxValue(maxValue uint8, method DitherMethod, serpentine bool) error {
+	if maxValue == 0 {
+		return errors.New("max value must be positive")
+	}
+	planes := planesPPM(ppm)
+	var levels [3][][]int
+	for c := range planes {
+		levels[c] = ditherPlane(planes[c], ppm.max, int(maxValue)+1, method, serpentine)
+	}
+	for y := range ppm.data {
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{R: uint8(levels[0][y][x]), G: uint8(levels[1][y][x]), B: uint8(levels[2][y][x])}
+		}
+	}
+	ppm.max = maxValue
+	return nil
+}
@@ -0,0 +1,103 @@
+package Netpbm
+
+import (
+	"math"
+	"testing"
+)
+
+// flatPGM returns a width x height PGM image where every sample is value.
+func flatPGM(width, height int, value, max uint8) *PGM {
+	pgm := &PGM{data: make([][]uint8, height), width: width, height: height, magicNumber: "P2", max: max}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, width)
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = value
+		}
+	}
+	return pgm
+}
+
+func TestDitherKeepsTone(t *testing.T) {
+	methods := []DitherMethod{DitherFloydSteinberg, DitherJarvisJudiceNinke, DitherStucki, DitherAtkinson, DitherBayer2, DitherBayer4, DitherBayer8, DitherBayer16, DitherClusterDot, DitherBlueNoise}
+	for _, method := range methods {
+		if method == DitherAtkinson {
+			// Atkinson drops a quarter of the error by design
+			continue
+		}
+		for _, serpentine := range []bool{false, true} {
+			pbm := flatPGM(32, 32, 64, 255).Dither(method, serpentine)
+			var black int
+			for y := range pbm.data {
+				for x := range pbm.data[y] {
+					if pbm.data[y][x] {
+						black++
+					}
+				}
+			}
+			// A quarter of the full intensity: three quarters of black pixels
+			if ratio := float64(black) / (32 * 32); math.Abs(ratio-0.75) > 0.03 {
+				t.Errorf("Method %d, serpentine %v: %.2f black pixels", method, serpentine, ratio)
+			}
+		}
+	}
+}
+
+func TestBayerMatrix(t *testing.T) {
+	expected := [][]int{
+		{0, 8, 2, 10},
+		{12, 4, 14, 6},
+		{3, 11, 1, 9},
+		{15, 7, 13, 5},
+	}
+	matrix := bayerMatrix(4)
+	for y := range expected {
+		for x := range expected[y] {
+			if matrix[y][x] != expected[y][x] {
+				t.Fatalf("Got %v, expected %v", matrix, expected)
+			}
+		}
+	}
+}
+
+func TestBlueNoiseMatrix(t *testing.T) {
+	seen := make([]bool, 256)
+	for _, row := range DitherBlueNoise.thresholdMatrix() {
+		for _, v := range row {
+			if v < 0 || v >= 256 || seen[v] {
+				t.Fatalf("Rank %d out of range or repeated", v)
+			}
+			seen[v] = true
+		}
+	}
+}
+
+func TestDitherMaxValuePPM(t *testing.T) {
+	ppm := &PPM{data: make([][]Pixel, 8), width: 8, height: 8, magicNumber: "P3", max: 255}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, 8)
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{100, 100, 100}
+		}
+	}
+	if err := ppm.DitherMaxValue(3, DitherFloydSteinberg, true); err != nil {
+		t.Fatal(err)
+	}
+	if ppm.max != 3 {
+		t.Fatalf("Max value is %d", ppm.max)
+	}
+	var sum int
+	for y := range ppm.data {
+		for x := range ppm.data[y] {
+			if ppm.data[y][x].R > 3 {
+				t.Fatalf("Sample %d above the max value", ppm.data[y][x].R)
+			}
+			sum += int(ppm.data[y][x].R)
+		}
+	}
+	if mean := float64(sum) / 64 * 255 / 3; math.Abs(mean-100) > 5 {
+		t.Errorf("Mean tone %.1f, expected 100", mean)
+	}
+	if err := ppm.DitherMaxValue(0, DitherBayer4, false); err == nil {
+		t.Error("Expected an error for a zero max value")
+	}
+}
@@ -0,0 +1,152 @@
+package Netpbm
+
+import "math"
+
+// GradientOperator selects the pair of kernels used to estimate the gradient.
+type GradientOperator int
+
+const (
+	GradientSobel   GradientOperator = iota // [1 2 1] smoothing
+	GradientPrewitt                         // [1 1 1] smoothing
+	GradientScharr                          // [3 10 3] smoothing, better rotational symmetry
+)
+
+// kernels returns the horizontal and vertical derivative kernels of the
+// operator, normalized so that a ramp rising by one sample per pixel has a
+// gradient of one.
+func (op GradientOperator) kernels() (gx, gy *Kernel) {
+	var smooth []float64
+	switch op {
+	case GradientPrewitt:
+		smooth = []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	case GradientScharr:
+		smooth = []float64{3.0 / 16, 10.0 / 16, 3.0 / 16}
+	default:
+		smooth = []float64{0.25, 0.5, 0.25}
+	}
+	derive := []float64{-0.5, 0, 0.5}
+	gx, _ = SeparableKernel(derive, smooth)
+	gy, _ = SeparableKernel(smooth, derive)
+	return gx, gy
+}
+
+// gradients returns the horizontal and vertical derivatives of plane.
+func gradients(plane [][]float64, op GradientOperator) (gx, gy [][]float64) {
+	kx, ky := op.kernels()
+	return convolvePlane(plane, kx, EdgeClamp), convolvePlane(plane, ky, EdgeClamp)
+}
+
+// Gradient estimates the gradient of the PGM image with the given operator.
+// magnitude holds its norm, clamped to the max value, and direction its
+// angle, from -π at 0 to π at the max value, 0 pointing right and π/2 down.
+func (pgm *PGM) Gradient(op GradientOperator) (magnitude, direction *PGM) {
+	gx, gy := gradients(planePGM(pgm), op)
+	magnitude = &PGM{data: make([][]uint8, pgm.height), width: pgm.width, height: pgm.height, magicNumber: pgm.magicNumber, max: pgm.max}
+	direction = &PGM{data: make([][]uint8, pgm.height), width: pgm.width, height: pgm.height, magicNumber: pgm.magicNumber, max: pgm.max}
+	for y := 0; y < pgm.height; y++ {
+		magnitude.data[y] = make([]uint8, pgm.width)
+		direction.data[y] = make([]uint8, pgm.width)
+		for x := 0; x < pgm.width; x++ {
+			magnitude.data[y][x] = toSample(math.Hypot(gx[y][x], gy[y][x]), pgm.max)
+			angle := math.Atan2(gy[y][x], gx[y][x])
+			direction.data[y][x] = toSample((angle+math.Pi)/(2*math.Pi)*float64(pgm.max), pgm.max)
+		}
+	}
+	return magnitude, direction
+}
+
+// Canny detects edges in the PGM image with the Canny algorithm: the image
+// is smoothed by a Gaussian of standard deviation sigma (skipped when sigma
+// is not positive), Sobel gradients are thinned by non-maximum suppression,
+// and pixels are kept when their gradient is above high or when it is above
+// low and they are connected to such a pixel. low and high are fractions of
+// the max value. Edge pixels are black in the returned PBM image.
+func (pgm *PGM) Canny(sigma, low, high float64) *PBM {
+	plane := planePGM(pgm)
+	if sigma > 0 {
+		plane = convolvePlane(plane, GaussianKernel(sigma), EdgeClamp)
+	}
+	gx, gy := gradients(plane, GradientSobel)
+	mag := make([][]float64, pgm.height)
+	for y := range mag {
+		mag[y] = make([]float64, pgm.width)
+		for x := range mag[y] {
+			mag[y][x] = math.Hypot(gx[y][x], gy[y][x])
+		}
+	}
+	at := func(x, y int) float64 {
+		if y < 0 || y >= pgm.height || x < 0 || x >= pgm.width {
+			return 0
+		}
+		return mag[y][x]
+	}
+
+	// Non-maximum suppression along the gradient, quantized to 45°. Ties
+	// keep the first pixel only so that plateaus give one pixel wide edges.
+	lowLevel := low * float64(pgm.max)
+	highLevel := high * float64(pgm.max)
+	const (
+		weak = iota + 1
+		strong
+	)
+	class := make([][]uint8, pgm.height)
+	var stack [][2]int
+	for y := range class {
+		class[y] = make([]uint8, pgm.width)
+		for x := range class[y] {
+			m := mag[y][x]
+			if m <= lowLevel || m == 0 {
+				continue
+			}
+			angle := math.Atan2(gy[y][x], gx[y][x]) * 180 / math.Pi
+			if angle < 0 {
+				angle += 180
+			}
+			var dx, dy int
+			switch {
+			case angle < 22.5 || angle >= 157.5:
+				dx, dy = 1, 0
+			case angle < 67.5:
+				dx, dy = 1, 1
+			case angle < 112.5:
+				dx, dy = 0, 1
+			default:
+				dx, dy = -1, 1
+			}
+			if m < at(x+dx, y+dy) || m <= at(x-dx, y-dy) {
+				continue
+			}
+			if m > highLevel {
+				class[y][x] = strong
+				stack = append(stack, [2]int{x, y})
+			} else {
+				class[y][x] = weak
+			}
+		}
+	}
+
+	// Hysteresis: promote weak pixels connected to strong ones
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if y < 0 || y >= pgm.height || x < 0 || x >= pgm.width || class[y][x] != weak {
+					continue
+				}
+				class[y][x] = strong
+				stack = append(stack, [2]int{x, y})
+			}
+		}
+	}
+
+	edges := &PBM{data: make([][]bool, pgm.height), width: pgm.width, height: pgm.height, magicNumber: "P1"}
+	for y := range edges.data {
+		edges.data[y] = make([]bool, pgm.width)
+		for x := range edges.data[y] {
+			edges.data[y][x] = class[y][x] == strong
+		}
+	}
+	return edges
+}
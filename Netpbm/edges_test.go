@@ -0,0 +1,44 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+// stepPGM returns a 6x6 image, black on the left half and white on the right.
+func stepPGM() *PGM {
+	pgm := &PGM{data: make([][]uint8, 6), width: 6, height: 6, magicNumber: "P2", max: 8}
+	for y := range pgm.data {
+		pgm.data[y] = []uint8{0, 0, 0, 8, 8, 8}
+	}
+	return pgm
+}
+
+func TestGradientSobel(t *testing.T) {
+	magnitude, direction := stepPGM().Gradient(GradientSobel)
+	expected := []uint8{0, 0, 4, 4, 0, 0}
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			if magnitude.data[y][x] != expected[x] {
+				t.Errorf("Magnitude at (%d, %d) is %d, expected %d", x, y, magnitude.data[y][x], expected[x])
+			}
+		}
+	}
+	// A gradient pointing right has an angle of 0, halfway through the range
+	if direction.data[2][2] != 4 {
+		t.Errorf("Direction is %d, expected 4", direction.data[2][2])
+	}
+}
+
+func TestCanny(t *testing.T) {
+	pbm := stepPGM().Canny(0, 0.1, 0.3)
+	if pbm.magicNumber != "P1" || pbm.width != 6 || pbm.height != 6 {
+		t.Fatalf("Wrong header %s %dx%d", pbm.magicNumber, pbm.width, pbm.height)
+	}
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			if pbm.data[y][x] != (x == 2) {
+				t.Errorf("Pixel at (%d, %d) is %v", x, y, pbm.data[y][x])
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package Netpbm
+
+import "math"
+
+// GrayMethod selects how a colour is reduced to a grey level.
+type GrayMethod int
+
+const (
+	GrayRec601  GrayMethod = iota // 0.299 R + 0.587 G + 0.114 B, SD video and JPEG
+	GrayRec709                    // 0.2126 R + 0.7152 G + 0.0722 B, HD video
+	GrayRec2020                   // 0.2627 R + 0.6780 G + 0.0593 B, UHD video
+	GrayLinear                    // Rec.709 luminance of the sRGB-decoded samples, re-encoded
+	GrayAverage                   // (R + G + B) / 3, rounded where ToPGM truncates
+	GrayRed                       // red channel only
+	GrayGreen                     // green channel only
+	GrayBlue                      // blue channel only
+	GrayCustom                    // Weights, divided by their sum
+)
+
+// GrayOptions configures the conversion of a PPM image to PGM. The zero
+// value uses GrayRec601.
+type GrayOptions struct {
+	Method  GrayMethod
+	Weights [3]float64 // red, green and blue weights of GrayCustom
+	Max     uint8      // max value of the result, the one of the image when zero
+}
+
+// weights returns the red, green and blue weights of a weighted sum method.
+func (opts GrayOptions) weights() [3]float64 {
+	switch opts.Method {
+	case GrayRec709, GrayLinear:
+		return [3]float64{0.2126, 0.7152, 0.0722}
+	case GrayRec2020:
+		return [3]float64{0.2627, 0.6780, 0.0593}
+	case GrayRed:
+		return [3]float64{1, 0, 0}
+	case GrayGreen:
+		return [3]float64{0, 1, 0}
+	case GrayBlue:
+		return [3]float64{0, 0, 1}
+	case GrayCustom:
+		w := opts.Weights
+		if sum := w[0] + w[1] + w[2]; sum != 0 {
+			return [3]float64{w[0] / sum, w[1] / sum, w[2] / sum}
+		}
+		return w
+	case GrayAverage:
+		return [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	}
+	return [3]float64{0.299, 0.587, 0.114}
+}
+
+// srgbToLinear decodes an sRGB encoded intensity in [0, 1].
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB encodes a linear intensity in [0, 1] with the sRGB curve.
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// ToPGMWith converts the PPM image to PGM with the grey level formula and
+// the max value of opts. Unlike ToPGM, which averages the channels and makes
+// greens too dark and blues too bright, the Rec. methods weight each channel
+// by its perceived brightness. GrayRec601, the default, is the usual choice
+// for images of unknown origin.
+func (ppm *PPM) ToPGMWith(opts GrayOptions) *PGM {
+	max := opts.Max
+	if max == 0 {
+		max = ppm.max
+	}
+	pgm := &PGM{
+		width:       ppm.width,
+		height:      ppm.height,
+		magicNumber: "P2",
+		max:         max,
+		data:        make([][]uint8, ppm.height),
+	}
+	if ppm.max == 0 {
+		for y := range pgm.data {
+			pgm.data[y] = make([]uint8, ppm.width)
+		}
+		return pgm
+	}
+	w := opts.weights()
+	in := float64(ppm.max)
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, ppm.width)
+		for x := range pgm.data[y] {
+			p := ppm.data[y][x]
+			r, g, b := float64(p.R)/in, float64(p.G)/in, float64(p.B)/in
+			var gray float64
+			if opts.Method == GrayLinear {
+				gray = linearToSRGB(w[0]*srgbToLinear(r) + w[1]*srgbToLinear(g) + w[2]*srgbToLinear(b))
+			} else {
+				gray = w[0]*r + w[1]*g + w[2]*b
+			}
+			pgm.data[y][x] = toSample(gray*float64(max), max)
+		}
+	}
+	return pgm
+}
@@ -0,0 +1,41 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestToPGMWith(t *testing.T) {
+	ppm := &PPM{
+		data:        [][]Pixel{{{0, 255, 0}, {0, 0, 255}, {255, 255, 255}, {200, 100, 50}}},
+		width:       4,
+		height:      1,
+		magicNumber: "P3",
+		max:         255,
+	}
+	tests := []struct {
+		opts     GrayOptions
+		expected []uint8
+	}{
+		{GrayOptions{Method: GrayAverage}, []uint8{85, 85, 255, 117}},
+		{GrayOptions{Method: GrayRec601}, []uint8{150, 29, 255, 124}},
+		{GrayOptions{}, []uint8{150, 29, 255, 124}},
+		{GrayOptions{Method: GrayRec709}, []uint8{182, 18, 255, 118}},
+		{GrayOptions{Method: GrayRec2020}, []uint8{173, 15, 255, 123}},
+		{GrayOptions{Method: GrayLinear}, []uint8{220, 76, 255, 128}},
+		{GrayOptions{Method: GrayGreen}, []uint8{255, 0, 255, 100}},
+		{GrayOptions{Method: GrayCustom, Weights: [3]float64{1, 0, 1}}, []uint8{0, 128, 255, 125}},
+		{GrayOptions{Method: GrayRed, Max: 15}, []uint8{0, 0, 15, 12}},
+	}
+	for _, test := range tests {
+		pgm := ppm.ToPGMWith(test.opts)
+		if test.opts.Max != 0 && pgm.max != test.opts.Max || test.opts.Max == 0 && pgm.max != 255 {
+			t.Errorf("Method %d: max value is %d", test.opts.Method, pgm.max)
+		}
+		for x, v := range test.expected {
+			if pgm.data[0][x] != v {
+				t.Errorf("Method %d: got %v, expected %v", test.opts.Method, pgm.data[0], test.expected)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package Netpbm
+
+// erodeGray returns, for each pixel, the smallest sample covered by se
+// placed on it. Pixels outside of the image are ignored.
+func erodeGray(data [][]uint8, se *StructuringElement) [][]uint8 {
+	offsets := se.offsets()
+	out := make([][]uint8, len(data))
+	for y := range data {
+		out[y] = make([]uint8, len(data[y]))
+		for x := range data[y] {
+			v, found := uint8(255), false
+			for _, o := range offsets {
+				sx, sy := x+o[0], y+o[1]
+				if sy < 0 || sy >= len(data) || sx < 0 || sx >= len(data[sy]) {
+					continue
+				}
+				if s := data[sy][sx]; !found || s < v {
+					v, found = s, true
+				}
+			}
+			if !found {
+				v = data[y][x]
+			}
+			out[y][x] = v
+		}
+	}
+	return out
+}
+
+// dilateGray returns, for each pixel, the largest sample of the pixels whose
+// element covers it. Pixels outside of the image are ignored.
+func dilateGray(data [][]uint8, se *StructuringElement) [][]uint8 {
+	offsets := se.offsets()
+	out := make([][]uint8, len(data))
+	for y := range data {
+		out[y] = make([]uint8, len(data[y]))
+		for x := range data[y] {
+			v, found := uint8(0), false
+			for _, o := range offsets {
+				sx, sy := x-o[0], y-o[1]
+				if sy < 0 || sy >= len(data) || sx < 0 || sx >= len(data[sy]) {
+					continue
+				}
+				if s := data[sy][sx]; !found || s > v {
+					v, found = s, true
+				}
+			}
+			if !found {
+				v = data[y][x]
+			}
+			out[y][x] = v
+		}
+	}
+	return out
+}
+
+// Erode replaces each pixel of the PGM image by the darkest sample under the
+// flat element placed on it, shrinking bright areas. Pixels outside of the
+// image are ignored.
+func (pgm *PGM) Erode(se *StructuringElement) {
+	pgm.data = erodeGray(pgm.data, se)
+}
+
+// Dilate replaces each pixel of the PGM image by the brightest sample under
+// the reflected flat element, growing bright areas. Pixels outside of the
+// image are ignored.
+func (pgm *PGM) Dilate(se *StructuringElement) {
+	pgm.data = dilateGray(pgm.data, se)
+}
+
+// Open erodes then dilates the PGM image, flattening bright details smaller
+// than the element.
+func (pgm *PGM) Open(se *StructuringElement) {
+	pgm.data = dilateGray(erodeGray(pgm.data, se), se)
+}
+
+// Close dilates then erodes the PGM image, filling dark details smaller than
+// the element.
+func (pgm *PGM) Close(se *StructuringElement) {
+	pgm.data = erodeGray(dilateGray(pgm.data, se), se)
+}
+
+// MorphGradient replaces the PGM image by the difference between its
+// dilation and its erosion, which outlines the edges of objects. With an
+// element that does not contain its origin the erosion may exceed the
+// dilation; such differences are taken as 0.
+func (pgm *PGM) MorphGradient(se *StructuringElement) {
+	dilated := dilateGray(pgm.data, se)
+	eroded := erodeGray(pgm.data, se)
+	for y := range pgm.data {
+		for x := range pgm.data[y] {
+			if dilated[y][x] > eroded[y][x] {
+				pgm.data[y][x] = dilated[y][x] - eroded[y][x]
+			} else {
+				pgm.data[y][x] = 0
+			}
+		}
+	}
+}
+
+// TopHat replaces the PGM image by its difference with its opening, keeping
+// bright details smaller than the element. With an element larger than the
+// objects of interest this subtracts an unevenly lit background. As with
+// MorphGradient, negative differences are taken as 0.
+func (pgm *PGM) TopHat(se *StructuringElement) {
+	opened := dilateGray(erodeGray(pgm.data, se), se)
+	for y := range pgm.data {
+		for x := range pgm.data[y] {
+			if pgm.data[y][x] > opened[y][x] {
+				pgm.data[y][x] -= opened[y][x]
+			} else {
+				pgm.data[y][x] = 0
+			}
+		}
+	}
+}
+
+// BlackHat replaces the PGM image by the difference between its closing and
+// itself, keeping dark details smaller than the element. Negative differences
+// are taken as 0.
+func (pgm *PGM) BlackHat(se *StructuringElement) {
+	closed := erodeGray(dilateGray(pgm.data, se), se)
+	for y := range pgm.data {
+		for x := range pgm.data[y] {
+			if closed[y][x] > pgm.data[y][x] {
+				pgm.data[y][x] = closed[y][x] - pgm.data[y][x]
+			} else {
+				pgm.data[y][x] = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+// spotsPGM returns a flat PGM image with a small bright spot and a small
+// dark spot.
+func spotsPGM() *PGM {
+	pgm := &PGM{data: make([][]uint8, 7), width: 9, height: 7, magicNumber: "P2", max: 255}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, 9)
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = 100
+		}
+	}
+	pgm.data[2][2] += 50
+	pgm.data[4][6] -= 50
+	return pgm
+}
+
+func TestTopHatPGM(t *testing.T) {
+	pgm := spotsPGM()
+	pgm.TopHat(SquareElement(1))
+	for y := range pgm.data {
+		for x := range pgm.data[y] {
+			expected := uint8(0)
+			if x == 2 && y == 2 {
+				expected = 50
+			}
+			if pgm.data[y][x] != expected {
+				t.Errorf("Pixel at (%d, %d) is %d, expected %d", x, y, pgm.data[y][x], expected)
+			}
+		}
+	}
+}
+
+func TestBlackHatPGM(t *testing.T) {
+	pgm := spotsPGM()
+	pgm.BlackHat(DiskElement(1))
+	for y := range pgm.data {
+		for x := range pgm.data[y] {
+			expected := uint8(0)
+			if x == 6 && y == 4 {
+				expected = 50
+			}
+			if pgm.data[y][x] != expected {
+				t.Errorf("Pixel at (%d, %d) is %d, expected %d", x, y, pgm.data[y][x], expected)
+			}
+		}
+	}
+}
+
+func TestErodeDilatePGM(t *testing.T) {
+	pgm := &PGM{data: [][]uint8{{1, 5, 3}}, width: 3, height: 1, magicNumber: "P2", max: 9}
+	pgm.Dilate(SquareElement(1))
+	if pgm.data[0][0] != 5 || pgm.data[0][1] != 5 || pgm.data[0][2] != 5 {
+		t.Errorf("Dilation gave %v", pgm.data[0])
+	}
+	pgm = &PGM{data: [][]uint8{{1, 5, 3}}, width: 3, height: 1, magicNumber: "P2", max: 9}
+	pgm.Erode(SquareElement(1))
+	if pgm.data[0][0] != 1 || pgm.data[0][1] != 1 || pgm.data[0][2] != 3 {
+		t.Errorf("Erosion gave %v", pgm.data[0])
+	}
+	pgm = &PGM{data: [][]uint8{{1, 5, 3}}, width: 3, height: 1, magicNumber: "P2", max: 9}
+	pgm.MorphGradient(SquareElement(1))
+	if pgm.data[0][0] != 4 || pgm.data[0][1] != 4 || pgm.data[0][2] != 2 {
+		t.Errorf("Gradient gave %v", pgm.data[0])
+	}
+}
+
+func TestGrayMorphologyOriginOutside(t *testing.T) {
+	// The element only covers the right neighbour, so the erosion can exceed
+	// the dilation on an increasing ramp, and the opening and closing can
+	// fall on either side of the image
+	se, err := ElementFromPBM(bitmap(".#"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []func(pgm *PGM, se *StructuringElement){(*PGM).MorphGradient, (*PGM).TopHat, (*PGM).BlackHat} {
+		pgm := &PGM{data: [][]uint8{{0, 3, 6, 9}}, width: 4, height: 1, magicNumber: "P2", max: 9}
+		op(pgm, se)
+		for x, v := range pgm.data[0] {
+			if v > pgm.max {
+				t.Errorf("Pixel %d wrapped around to %d", x, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// HashMethod selects the perceptual hash computed by Hash.
+type HashMethod int
+
+const (
+	HashAverage    HashMethod = iota // 8x8 thumbnail compared with its mean
+	HashDifference                   // 9x8 thumbnail, each pixel compared with its right neighbour
+	HashPerceptual                   // low frequencies of the DCT of a 32x32 thumbnail compared with their median
+	HashWavelet                      // Haar approximation of a 64x64 thumbnail compared with its median
+)
+
+// hashBits packs 64 comparisons, the first one in the most significant bit.
+func hashBits(values []float64, set func(i int, v float64) bool) uint64 {
+	var hash uint64
+	for i, v := range values {
+		hash <<= 1
+		if set(i, v) {
+			hash |= 1
+		}
+	}
+	return hash
+}
+
+// thumbnail returns the plane scaled down to width x height by averaging,
+// row after row.
+func thumbnail(plane [][]float64, width, height int) []float64 {
+	scaled := resample(plane, width, height, FilterBox)
+	values := make([]float64, 0, width*height)
+	for y := range scaled {
+		values = append(values, scaled[y]...)
+	}
+	return values
+}
+
+// median returns the median of values.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// dct2D returns the n x n DCT-II of values stored row after row.
+func dct2D(values []float64, n int) []float64 {
+	basis := make([][]float64, n)
+	for k := range basis {
+		basis[k] = make([]float64, n)
+		for i := range basis[k] {
+			basis[k][i] = math.Cos(math.Pi / float64(n) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+	rows := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += values[y*n+x] * basis[k][x]
+			}
+			rows[y*n+k] = sum
+		}
+	}
+	out := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y*n+x] * basis[k][y]
+			}
+			out[k*n+x] = sum
+		}
+	}
+	return out
+}
+
+// haarApproximation applies levels steps of the 2D Haar transform to the
+// n x n values stored row after row and returns the approximation band.
+func haarApproximation(values []float64, n, levels int) []float64 {
+	for ; levels > 0; levels-- {
+		half := n / 2
+		next := make([]float64, half*half)
+		for y := 0; y < half; y++ {
+			for x := 0; x < half; x++ {
+				i := 2*y*n + 2*x
+				next[y*half+x] = (values[i] + values[i+1] + values[i+n] + values[i+n+1]) / 2
+			}
+		}
+		values, n = next, half
+	}
+	return values
+}
+
+// hashPlane returns the perceptual hash of a plane of samples.
+func hashPlane(plane [][]float64, method HashMethod) (uint64, error) {
+	if len(plane) == 0 || len(plane[0]) == 0 {
+		return 0, errors.New("image is empty")
+	}
+	switch method {
+	case HashAverage:
+		values := thumbnail(plane, 8, 8)
+		var mean float64
+		for _, v := range values {
+			mean += v / 64
+		}
+		return hashBits(values, func(_ int, v float64) bool { return v > mean }), nil
+	case HashDifference:
+		values := thumbnail(plane, 9, 8)
+		compared := make([]float64, 64)
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				compared[y*8+x] = values[y*9+x+1] - values[y*9+x]
+			}
+		}
+		return hashBits(compared, func(_ int, d float64) bool { return d > 0 }), nil
+	case HashPerceptual:
+		dct := dct2D(thumbnail(plane, 32, 32), 32)
+		low := make([]float64, 64)
+		for y := 0; y < 8; y++ {
+			copy(low[y*8:], dct[y*32:y*32+8])
+		}
+		// The constant term only measures brightness, leave it out of the median
+		m := median(low[1:])
+		return hashBits(low, func(_ int, v float64) bool { return v > m }), nil
+	case HashWavelet:
+		values := haarApproximation(thumbnail(plane, 64, 64), 64, 3)
+		m := median(values)
+		return hashBits(values, func(_ int, v float64) bool { return v > m }), nil
+	}
+	return 0, errors.New("invalid hash method")
+}
+
+// Hash returns a 64 bit perceptual hash of the PGM image. Images that look
+// alike have hashes at a small HammingDistance, whatever their size.
+func (pgm *PGM) Hash(method HashMethod) (uint64, error) {
+	return hashPlane(planePGM(pgm), method)
+}
+
+// Hash returns a 64 bit perceptual hash of the ToPGM conversion of the PPM
+// image; see PGM.Hash.
+func (ppm *PPM) Hash(method HashMethod) (uint64, error) {
+	return ppm.ToPGM().Hash(method)
+}
+
+// HammingDistance returns the number of bits that differ between two
+// hashes, from 0 for identical hashes to 64.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
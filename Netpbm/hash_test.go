@@ -0,0 +1,48 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	original := patternPGM(0)
+	// Same picture, slightly brighter and at a different size
+	similar := patternPGM(0)
+	similar.BrightnessContrast(0.05, 1)
+	if err := similar.Resize(48, 40, FilterBilinear); err != nil {
+		t.Fatal(err)
+	}
+	different := patternPGM(0)
+	different.Rotate90CW()
+	different.Invert()
+
+	for _, method := range []HashMethod{HashAverage, HashDifference, HashPerceptual, HashWavelet} {
+		a, err := original.Hash(method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := similar.Hash(method)
+		c, _ := different.Hash(method)
+		if d := HammingDistance(a, b); d > 10 {
+			t.Errorf("Method %d: similar images are %d bits apart", method, d)
+		}
+		if d := HammingDistance(a, c); d < 20 {
+			t.Errorf("Method %d: different images are %d bits apart", method, d)
+		}
+	}
+	if _, err := original.Hash(HashMethod(42)); err == nil {
+		t.Error("Expected an error for an invalid method")
+	}
+	if _, err := (&PGM{max: 255}).Hash(HashAverage); err == nil {
+		t.Error("Expected an error for an empty image")
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	if d := HammingDistance(0xF0, 0x0F); d != 8 {
+		t.Errorf("Got %d", d)
+	}
+	if d := HammingDistance(42, 42); d != 0 {
+		t.Errorf("Got %d", d)
+	}
+}
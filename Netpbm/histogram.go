@@ -0,0 +1,228 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// cumulate returns the running sums of counts.
+func cumulate(counts []int) []int {
+	cumulative := make([]int, len(counts))
+	var sum int
+	for v, n := range counts {
+		sum += n
+		cumulative[v] = sum
+	}
+	return cumulative
+}
+
+// Histogram returns the number of pixels of the PGM image for each sample
+// value from 0 to the max value.
+func (pgm *PGM) Histogram() []int {
+	return histogram(pgm.data, pgm.max)
+}
+
+// CumulativeHistogram returns the number of pixels of the PGM image at or
+// below each sample value from 0 to the max value.
+func (pgm *PGM) CumulativeHistogram() []int {
+	return cumulate(pgm.Histogram())
+}
+
+// Histogram returns, for each channel of the PPM image, the number of pixels
+// for each sample value from 0 to the max value.
+func (ppm *PPM) Histogram() (red, green, blue []int) {
+	channels := channelsPPM(ppm)
+	return histogram(channels[0], ppm.max), histogram(channels[1], ppm.max), histogram(channels[2], ppm.max)
+}
+
+// CumulativeHistogram returns, for each channel of the PPM image, the number
+// of pixels at or below each sample value from 0 to the max value.
+func (ppm *PPM) CumulativeHistogram() (red, green, blue []int) {
+	r, g, b := ppm.Histogram()
+	return cumulate(r), cumulate(g), cumulate(b)
+}
+
+// equalization returns the lookup table spreading the samples counted in
+// counts evenly over [0, max].
+func equalization(counts []int, max uint8) []uint8 {
+	cumulative := cumulate(counts)
+	lut := make([]uint8, len(counts))
+	var first int
+	for _, c := range cumulative {
+		if c > 0 {
+			first = c
+			break
+		}
+	}
+	total := cumulative[len(cumulative)-1]
+	for v, c := range cumulative {
+		if total == first {
+			lut[v] = uint8(v)
+			continue
+		}
+		lut[v] = toSample(float64(c-first)/float64(total-first)*float64(max), max)
+	}
+	return lut
+}
+
+// matching returns the lookup table giving samples counted in counts the
+// distribution of the samples counted in reference, whose max value is
+// refMax, rescaled to max.
+func matching(counts, reference []int, refMax, max uint8) []uint8 {
+	cumulative := cumulate(counts)
+	refCumulative := cumulate(reference)
+	total := float64(cumulative[len(cumulative)-1])
+	refTotal := float64(refCumulative[len(refCumulative)-1])
+	lut := make([]uint8, len(counts))
+	u := 0
+	for v, c := range cumulative {
+		target := float64(c) / total
+		for u < len(refCumulative)-1 && float64(refCumulative[u])/refTotal < target {
+			u++
+		}
+		lut[v] = rescale(uint8(u), refMax, max)
+	}
+	return lut
+}
+
+// applyLUT replaces each sample of data by its entry in lut.
+func applyLUT(data [][]uint8, lut []uint8) {
+	for y := range data {
+		for x, v := range data[y] {
+			if int(v) < len(lut) {
+				data[y][x] = lut[v]
+			}
+		}
+	}
+}
+
+// Equalize spreads the samples of the PGM image so that every level is about
+// equally used, which increases the contrast of low contrast images.
+func (pgm *PGM) Equalize() {
+	applyLUT(pgm.data, equalization(pgm.Histogram(), pgm.max))
+}
+
+// Equalize equalizes each channel of the PPM image independently. This may
+// shift colours.
+func (ppm *PPM) Equalize() {
+	r, g, b := ppm.Histogram()
+	lr, lg, lb := equalization(r, ppm.max), equalization(g, ppm.max), equalization(b, ppm.max)
+	for y := range ppm.data {
+		for x, p := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{R: lr[p.R], G: lg[p.G], B: lb[p.B]}
+		}
+	}
+}
+
+// MatchHistogram remaps the samples of the PGM image so that their
+// distribution matches the one of reference.
+func (pgm *PGM) MatchHistogram(reference *PGM) error {
+	if reference.width*reference.height == 0 {
+		return errors.New("reference image is empty")
+	}
+	if pgm.width*pgm.height == 0 {
+		return nil
+	}
+	applyLUT(pgm.data, matching(pgm.Histogram(), reference.Histogram(), reference.max, pgm.max))
+	return nil
+}
+
+// MatchHistogram remaps each channel of the PPM image so that its
+// distribution matches the one of the same channel of reference.
+func (ppm *PPM) MatchHistogram(reference *PPM) error {
+	if reference.width*reference.height == 0 {
+		return errors.New("reference image is empty")
+	}
+	if ppm.width*ppm.height == 0 {
+		return nil
+	}
+	r, g, b := ppm.Histogram()
+	rr, rg, rb := reference.Histogram()
+	lr := matching(r, rr, reference.max, ppm.max)
+	lg := matching(g, rg, reference.max, ppm.max)
+	lb := matching(b, rb, reference.max, ppm.max)
+	for y := range ppm.data {
+		for x, p := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{R: lr[p.R], G: lg[p.G], B: lb[p.B]}
+		}
+	}
+	return nil
+}
+
+// CLAHE applies contrast limited adaptive histogram equalization to the PGM
+// image. The image is divided into a grid of tilesX x tilesY tiles, each
+// equalized with its own histogram whose bins are clipped at clipLimit times
+// their average count, the excess being spread over all bins; this limits
+// the amplification of noise. Pixels are remapped by interpolating the
+// mappings of the four nearest tiles. A clipLimit of 1 or less leaves the
+// image almost unchanged, larger values give more contrast, and zero
+// disables clipping.
+func (pgm *PGM) CLAHE(tilesX, tilesY int, clipLimit float64) error {
+	if tilesX <= 0 || tilesY <= 0 || tilesX > pgm.width || tilesY > pgm.height {
+		return errors.New("invalid tile grid")
+	}
+	bins := int(pgm.max) + 1
+	luts := make([][][]float64, tilesY)
+	for ty := range luts {
+		luts[ty] = make([][]float64, tilesX)
+		y0, y1 := ty*pgm.height/tilesY, (ty+1)*pgm.height/tilesY
+		for tx := range luts[ty] {
+			x0, x1 := tx*pgm.width/tilesX, (tx+1)*pgm.width/tilesX
+			counts := make([]float64, bins)
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					counts[pgm.data[y][x]]++
+				}
+			}
+			area := float64((y1 - y0) * (x1 - x0))
+			if clipLimit > 0 {
+				limit := math.Max(clipLimit*area/float64(bins), 1)
+				var excess float64
+				for v := range counts {
+					if counts[v] > limit {
+						excess += counts[v] - limit
+						counts[v] = limit
+					}
+				}
+				for v := range counts {
+					counts[v] += excess / float64(bins)
+				}
+			}
+			lut := make([]float64, bins)
+			var sum float64
+			for v := range counts {
+				sum += counts[v]
+				lut[v] = sum / area * float64(pgm.max)
+			}
+			luts[ty][tx] = lut
+		}
+	}
+
+	// Position of a pixel in tile units, tile centres being at integers
+	tileCoord := func(i, size, tiles int) (int, int, float64) {
+		t := (float64(i)+0.5)*float64(tiles)/float64(size) - 0.5
+		t0 := int(math.Floor(t))
+		f := t - float64(t0)
+		t1 := t0 + 1
+		if t0 < 0 {
+			t0, f = 0, 0
+		}
+		if t1 >= tiles {
+			t1 = tiles - 1
+		}
+		if t0 >= tiles {
+			t0 = tiles - 1
+		}
+		return t0, t1, f
+	}
+	for y := range pgm.data {
+		ty0, ty1, fy := tileCoord(y, pgm.height, tilesY)
+		for x, v := range pgm.data[y] {
+			tx0, tx1, fx := tileCoord(x, pgm.width, tilesX)
+			top := luts[ty0][tx0][v]*(1-fx) + luts[ty0][tx1][v]*fx
+			bottom := luts[ty1][tx0][v]*(1-fx) + luts[ty1][tx1][v]*fx
+			pgm.data[y][x] = toSample(top*(1-fy)+bottom*fy, pgm.max)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestHistogramPGM(t *testing.T) {
+	pgm, err := ReadPGM("./testImages/pgm/testP2.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := make([]int, imagePGMMax+1)
+	for _, v := range testData {
+		expected[v]++
+	}
+	histogram := pgm.Histogram()
+	cumulative := pgm.CumulativeHistogram()
+	if len(histogram) != imagePGMMax+1 {
+		t.Fatalf("Histogram has %d bins", len(histogram))
+	}
+	var sum int
+	for v := range expected {
+		sum += expected[v]
+		if histogram[v] != expected[v] || cumulative[v] != sum {
+			t.Errorf("Value %d: count %d, cumulative %d", v, histogram[v], cumulative[v])
+		}
+	}
+}
+
+func TestEqualizePGM(t *testing.T) {
+	// Low contrast: samples only between 100 and 103
+	pgm := &PGM{data: [][]uint8{{100, 101, 102, 103}}, width: 4, height: 1, magicNumber: "P2", max: 255}
+	pgm.Equalize()
+	expected := []uint8{0, 85, 170, 255}
+	for x := range expected {
+		if pgm.data[0][x] != expected[x] {
+			t.Fatalf("Got %v, expected %v", pgm.data[0], expected)
+		}
+	}
+}
+
+func TestMatchHistogramPGM(t *testing.T) {
+	pgm := &PGM{data: [][]uint8{{0, 1, 2, 3}}, width: 4, height: 1, magicNumber: "P2", max: 3}
+	reference := &PGM{data: [][]uint8{{10, 10, 20, 20}}, width: 4, height: 1, magicNumber: "P2", max: 30}
+	if err := pgm.MatchHistogram(reference); err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint8{1, 1, 2, 2}
+	for x := range expected {
+		if pgm.data[0][x] != expected[x] {
+			t.Fatalf("Got %v, expected %v", pgm.data[0], expected)
+		}
+	}
+}
+
+func TestCLAHE(t *testing.T) {
+	// Two halves of different brightness, each with little contrast
+	pgm := &PGM{data: make([][]uint8, 8), width: 8, height: 8, magicNumber: "P2", max: 255}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, 8)
+		for x := range pgm.data[y] {
+			base := 40
+			if x >= 4 {
+				base = 200
+			}
+			pgm.data[y][x] = uint8(base + (x+y)%2*10)
+		}
+	}
+	if err := pgm.CLAHE(2, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Inside a tile, the two levels must now be far apart
+	if d := int(pgm.data[0][1]) - int(pgm.data[0][0]); d < 50 {
+		t.Errorf("Contrast of the dark half is %d", d)
+	}
+	if d := int(pgm.data[0][7]) - int(pgm.data[0][6]); d < 50 {
+		t.Errorf("Contrast of the bright half is %d", d)
+	}
+	if err := pgm.CLAHE(0, 2, 4); err == nil {
+		t.Error("Expected an error for an empty grid")
+	}
+}
@@ -0,0 +1,180 @@
+package Netpbm
+
+import "math"
+
+// Interpolation selects how a sample is computed at a position that falls
+// between pixel centres.
+type Interpolation int
+
+const (
+	InterpNearest  Interpolation = iota // value of the closest pixel
+	InterpBilinear                      // weighted average of the 2x2 neighbourhood
+	InterpBicubic                       // Catmull-Rom spline over the 4x4 neighbourhood
+)
+
+// sampler returns the value of a plane at integer coordinates, including
+// coordinates that fall outside of the image.
+type sampler func(x, y int) float64
+
+// interpolate returns the value at the real coordinates (x, y), pixel centres
+// being at integer coordinates.
+func interpolate(get sampler, x, y float64, interp Interpolation) float64 {
+	switch interp {
+	case InterpBilinear:
+		x0, y0 := math.Floor(x), math.Floor(y)
+		fx, fy := x-x0, y-y0
+		ix, iy := int(x0), int(y0)
+		top := get(ix, iy)*(1-fx) + get(ix+1, iy)*fx
+		bottom := get(ix, iy+1)*(1-fx) + get(ix+1, iy+1)*fx
+		return top*(1-fy) + bottom*fy
+	case InterpBicubic:
+		x0, y0 := math.Floor(x), math.Floor(y)
+		fx, fy := x-x0, y-y0
+		ix, iy := int(x0), int(y0)
+		var value float64
+		for j := -1; j <= 2; j++ {
+			wy := cubicWeight(float64(j) - fy)
+			for i := -1; i <= 2; i++ {
+				value += get(ix+i, iy+j) * cubicWeight(float64(i)-fx) * wy
+			}
+		}
+		return value
+	default:
+		return get(int(math.Floor(x+0.5)), int(math.Floor(y+0.5)))
+	}
+}
+
+// cubicWeight is the Catmull-Rom kernel (a = -0.5).
+func cubicWeight(t float64) float64 {
+	t = math.Abs(t)
+	switch {
+	case t < 1:
+		return 1.5*t*t*t - 2.5*t*t + 1
+	case t < 2:
+		return -0.5*t*t*t + 2.5*t*t - 4*t + 2
+	}
+	return 0
+}
+
+// EdgeMode selects the value read for coordinates outside of an image.
+type EdgeMode int
+
+const (
+	EdgeConstant EdgeMode = iota // a fixed fill value
+	EdgeClamp                    // the nearest edge pixel
+	EdgeWrap                     // the image repeats periodically
+	EdgeReflect                  // the image is mirrored, edge pixels included
+)
+
+// edgeIndex maps i into [0, n) according to mode. It returns false for
+// EdgeConstant when i is out of range, and for every mode when n is zero.
+func edgeIndex(i, n int, mode EdgeMode) (int, bool) {
+	if n <= 0 {
+		return 0, false
+	}
+	if i >= 0 && i < n {
+		return i, true
+	}
+	switch mode {
+	case EdgeClamp:
+		if i < 0 {
+			return 0, true
+		}
+		return n - 1, true
+	case EdgeWrap:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+		return i, true
+	case EdgeReflect:
+		period := 2 * n
+		i %= period
+		if i < 0 {
+			i += period
+		}
+		if i >= n {
+			i = period - 1 - i
+		}
+		return i, true
+	}
+	return 0, false
+}
+
+// edgeSampler reads plane, handling coordinates outside of it according to
+// mode. fill is only used by EdgeConstant.
+func edgeSampler(plane [][]float64, mode EdgeMode, fill float64) sampler {
+	return func(x, y int) float64 {
+		if len(plane) == 0 {
+			return fill
+		}
+		y, ok := edgeIndex(y, len(plane), mode)
+		if !ok {
+			return fill
+		}
+		x, ok = edgeIndex(x, len(plane[y]), mode)
+		if !ok {
+			return fill
+		}
+		return plane[y][x]
+	}
+}
+
+// toSample rounds v and clamps it to the range [0, max].
+func toSample(v float64, max uint8) uint8 {
+	v = math.Round(v)
+	if v < 0 {
+		return 0
+	}
+	if v > float64(max) {
+		return max
+	}
+	return uint8(v)
+}
+
+// planePGM returns the samples of the PGM image as floats.
+func planePGM(pgm *PGM) [][]float64 {
+	plane := make([][]float64, pgm.height)
+	for y := range plane {
+		plane[y] = make([]float64, pgm.width)
+		for x := range plane[y] {
+			plane[y][x] = float64(pgm.data[y][x])
+		}
+	}
+	return plane
+}
+
+// planesPPM returns the red, green and blue samples of the PPM image as floats.
+func planesPPM(ppm *PPM) [3][][]float64 {
+	var planes [3][][]float64
+	for c := range planes {
+		planes[c] = make([][]float64, ppm.height)
+	}
+	for y := 0; y < ppm.height; y++ {
+		for c := range planes {
+			planes[c][y] = make([]float64, ppm.width)
+		}
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y][x]
+			planes[0][y][x] = float64(p.R)
+			planes[1][y][x] = float64(p.G)
+			planes[2][y][x] = float64(p.B)
+		}
+	}
+	return planes
+}
+
+// planePBM returns the PBM image as a plane where black pixels are 1 and
+// white pixels are 0.
+func planePBM(pbm *PBM) [][]float64 {
+	plane := make([][]float64, pbm.height)
+	for y := range plane {
+		plane[y] = make([]float64, pbm.width)
+		for x := range plane[y] {
+			if pbm.data[y][x] {
+				plane[y][x] = 1
+			}
+		}
+	}
+	return plane
+}
@@ -0,0 +1,338 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// StructuringElement is the shape probed by morphological operators, given
+// as a bitmap and the position of its origin in it.
+type StructuringElement struct {
+	data   [][]bool
+	cx, cy int
+}
+
+// newElement returns a (2*radius+1) square element centred on its origin,
+// setting the offsets for which inside returns true.
+func newElement(radius int, inside func(dx, dy int) bool) *StructuringElement {
+	if radius < 0 {
+		radius = 0
+	}
+	se := &StructuringElement{data: make([][]bool, 2*radius+1), cx: radius, cy: radius}
+	for y := range se.data {
+		se.data[y] = make([]bool, 2*radius+1)
+		for x := range se.data[y] {
+			se.data[y][x] = inside(x-radius, y-radius)
+		}
+	}
+	return se
+}
+
+// SquareElement returns a (2*radius+1) square.
+func SquareElement(radius int) *StructuringElement {
+	return newElement(radius, func(dx, dy int) bool { return true })
+}
+
+// CrossElement returns a cross with arms of length radius.
+func CrossElement(radius int) *StructuringElement {
+	return newElement(radius, func(dx, dy int) bool { return dx == 0 || dy == 0 })
+}
+
+// DiskElement returns a disk of the given radius.
+func DiskElement(radius int) *StructuringElement {
+	return newElement(radius, func(dx, dy int) bool {
+		return math.Hypot(float64(dx), float64(dy)) <= float64(radius)+0.5
+	})
+}
+
+// ElementFromPBM returns the structuring element made of the black pixels of
+// pbm, with its origin at (originX, originY).
+func ElementFromPBM(pbm *PBM, originX, originY int) (*StructuringElement, error) {
+	if originX < 0 || originX >= pbm.width || originY < 0 || originY >= pbm.height {
+		return nil, errors.New("origin is outside of the structuring element")
+	}
+	se := &StructuringElement{data: make([][]bool, pbm.height), cx: originX, cy: originY}
+	for y := range se.data {
+		se.data[y] = make([]bool, pbm.width)
+		copy(se.data[y], pbm.data[y])
+	}
+	return se, nil
+}
+
+// offsets lists the positions of the element relative to its origin.
+func (se *StructuringElement) offsets() [][2]int {
+	var offsets [][2]int
+	for y := range se.data {
+		for x := range se.data[y] {
+			if se.data[y][x] {
+				offsets = append(offsets, [2]int{x - se.cx, y - se.cy})
+			}
+		}
+	}
+	return offsets
+}
+
+// extent returns how far the element reaches from its origin.
+func (se *StructuringElement) extent() int {
+	var extent int
+	for _, o := range se.offsets() {
+		for _, d := range o {
+			if d < 0 {
+				d = -d
+			}
+			if d > extent {
+				extent = d
+			}
+		}
+	}
+	return extent
+}
+
+// padded applies op to data surrounded by a white margin as wide as the
+// reach of se, and returns the result cropped back to the size of data.
+// Compositions of erosions and dilations then behave as if the image were
+// surrounded by an infinite white plane.
+func padded(data [][]bool, se *StructuringElement, op func([][]bool) [][]bool) [][]bool {
+	m := se.extent()
+	if len(data) == 0 || m == 0 {
+		return op(data)
+	}
+	width := len(data[0])
+	canvas := make([][]bool, len(data)+2*m)
+	for y := range canvas {
+		canvas[y] = make([]bool, width+2*m)
+		if y >= m && y < m+len(data) {
+			copy(canvas[y][m:], data[y-m])
+		}
+	}
+	canvas = op(canvas)
+	out := canvas[m : m+len(data)]
+	for y := range out {
+		out[y] = out[y][m : m+width]
+	}
+	return out
+}
+
+// openBits erodes then dilates data by se.
+func openBits(data [][]bool, se *StructuringElement) [][]bool {
+	return padded(data, se, func(d [][]bool) [][]bool { return dilateBits(erodeBits(d, se), se) })
+}
+
+// closeBits dilates then erodes data by se.
+func closeBits(data [][]bool, se *StructuringElement) [][]bool {
+	return padded(data, se, func(d [][]bool) [][]bool { return erodeBits(dilateBits(d, se), se) })
+}
+
+// erodeBits returns the pixels of data where every offset of se lands on a
+// set pixel, pixels outside of the image being unset.
+func erodeBits(data [][]bool, se *StructuringElement) [][]bool {
+	offsets := se.offsets()
+	out := make([][]bool, len(data))
+	for y := range data {
+		out[y] = make([]bool, len(data[y]))
+		for x := range data[y] {
+			set := true
+			for _, o := range offsets {
+				sx, sy := x+o[0], y+o[1]
+				if sy < 0 || sy >= len(data) || sx < 0 || sx >= len(data[sy]) || !data[sy][sx] {
+					set = false
+					break
+				}
+			}
+			out[y][x] = set
+		}
+	}
+	return out
+}
+
+// dilateBits returns the pixels of data reached by se placed on any set pixel.
+func dilateBits(data [][]bool, se *StructuringElement) [][]bool {
+	offsets := se.offsets()
+	out := make([][]bool, len(data))
+	for y := range data {
+		out[y] = make([]bool, len(data[y]))
+		for x := range data[y] {
+			for _, o := range offsets {
+				sx, sy := x-o[0], y-o[1]
+				if sy >= 0 && sy < len(data) && sx >= 0 && sx < len(data[sy]) && data[sy][sx] {
+					out[y][x] = true
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Erode shrinks the black areas of the PBM image: a pixel stays black only if
+// the element placed on it covers black pixels only. Pixels outside of the
+// image count as white.
+func (pbm *PBM) Erode(se *StructuringElement) {
+	pbm.data = erodeBits(pbm.data, se)
+}
+
+// Dilate grows the black areas of the PBM image by the element.
+func (pbm *PBM) Dilate(se *StructuringElement) {
+	pbm.data = dilateBits(pbm.data, se)
+}
+
+// Open erodes then dilates the PBM image, removing black details smaller
+// than the element.
+func (pbm *PBM) Open(se *StructuringElement) {
+	pbm.data = openBits(pbm.data, se)
+}
+
+// Close dilates then erodes the PBM image, filling white gaps smaller than
+// the element.
+func (pbm *PBM) Close(se *StructuringElement) {
+	pbm.data = closeBits(pbm.data, se)
+}
+
+// TopHat keeps the black details of the PBM image removed by an opening.
+func (pbm *PBM) TopHat(se *StructuringElement) {
+	opened := openBits(pbm.data, se)
+	for y := range pbm.data {
+		for x := range pbm.data[y] {
+			pbm.data[y][x] = pbm.data[y][x] && !opened[y][x]
+		}
+	}
+}
+
+// BlackHat keeps the white gaps of the PBM image filled by a closing.
+func (pbm *PBM) BlackHat(se *StructuringElement) {
+	closed := closeBits(pbm.data, se)
+	for y := range pbm.data {
+		for x := range pbm.data[y] {
+			pbm.data[y][x] = closed[y][x] && !pbm.data[y][x]
+		}
+	}
+}
+
+// HitOrMiss keeps the pixels of the PBM image where hit covers black pixels
+// only and miss covers white pixels only. Pixels outside of the image count
+// as white.
+func (pbm *PBM) HitOrMiss(hit, miss *StructuringElement) {
+	hits := erodeBits(pbm.data, hit)
+	missOffsets := miss.offsets()
+	for y := range pbm.data {
+		for x := range pbm.data[y] {
+			set := hits[y][x]
+			for _, o := range missOffsets {
+				if !set {
+					break
+				}
+				sx, sy := x+o[0], y+o[1]
+				if sy >= 0 && sy < len(pbm.data) && sx >= 0 && sx < len(pbm.data[sy]) && pbm.data[sy][sx] {
+					set = false
+				}
+			}
+			hits[y][x] = set
+		}
+	}
+	pbm.data = hits
+}
+
+// Thin reduces the black areas of the PBM image to lines one pixel wide that
+// keep their connectivity, using the Zhang-Suen algorithm.
+func (pbm *PBM) Thin() {
+	at := func(x, y int) bool {
+		return y >= 0 && y < pbm.height && x >= 0 && x < pbm.width && pbm.data[y][x]
+	}
+	var remove [][2]int
+	for changed := true; changed; {
+		changed = false
+		for pass := 0; pass < 2; pass++ {
+			remove = remove[:0]
+			for y := 0; y < pbm.height; y++ {
+				for x := 0; x < pbm.width; x++ {
+					if !pbm.data[y][x] {
+						continue
+					}
+					// Neighbours clockwise from north
+					n := [8]bool{at(x, y-1), at(x+1, y-1), at(x+1, y), at(x+1, y+1), at(x, y+1), at(x-1, y+1), at(x-1, y), at(x-1, y-1)}
+					var count, transitions int
+					for i := range n {
+						if n[i] {
+							count++
+						}
+						if !n[i] && n[(i+1)%8] {
+							transitions++
+						}
+					}
+					if count < 2 || count > 6 || transitions != 1 {
+						continue
+					}
+					if pass == 0 && (n[0] && n[2] && n[4] || n[2] && n[4] && n[6]) {
+						continue
+					}
+					if pass == 1 && (n[0] && n[2] && n[6] || n[0] && n[4] && n[6]) {
+						continue
+					}
+					remove = append(remove, [2]int{x, y})
+				}
+			}
+			for _, p := range remove {
+				pbm.data[p[1]][p[0]] = false
+			}
+			if len(remove) > 0 {
+				changed = true
+			}
+		}
+	}
+}
+
+// Skeleton replaces the PBM image by its morphological skeleton for the
+// element: the union, over successive erosions, of what an opening removes.
+// Unlike Thin the result may be disconnected, but the image can be rebuilt
+// from it. Pixels that erosion no longer removes, as with an element made of
+// its origin only, are kept.
+func (pbm *PBM) Skeleton(se *StructuringElement) {
+	if len(se.offsets()) == 0 {
+		return
+	}
+	skeleton := make([][]bool, len(pbm.data))
+	for y := range skeleton {
+		skeleton[y] = make([]bool, len(pbm.data[y]))
+	}
+	eroded := pbm.data
+	for {
+		var any bool
+		opened := openBits(eroded, se)
+		for y := range eroded {
+			for x := range eroded[y] {
+				if eroded[y][x] {
+					any = true
+					if !opened[y][x] {
+						skeleton[y][x] = true
+					}
+				}
+			}
+		}
+		if !any {
+			break
+		}
+		next := erodeBits(eroded, se)
+		if sameBits(next, eroded) {
+			for y := range eroded {
+				for x := range eroded[y] {
+					skeleton[y][x] = skeleton[y][x] || eroded[y][x]
+				}
+			}
+			break
+		}
+		eroded = next
+	}
+	pbm.data = skeleton
+}
+
+// sameBits reports whether a and b have the same pixels set.
+func sameBits(a, b [][]bool) bool {
+	for y := range a {
+		for x := range a[y] {
+			if a[y][x] != b[y][x] {
+				return false
+			}
+		}
+	}
+	return true
+}
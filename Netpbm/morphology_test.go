@@ -0,0 +1,160 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+// bitmap builds a PBM image from rows of '#' (black) and '.' (white).
+func bitmap(rows ...string) *PBM {
+	pbm := &PBM{data: make([][]bool, len(rows)), width: len(rows[0]), height: len(rows), magicNumber: "P1"}
+	for y, row := range rows {
+		pbm.data[y] = make([]bool, len(row))
+		for x, c := range row {
+			pbm.data[y][x] = c == '#'
+		}
+	}
+	return pbm
+}
+
+// compareBitmap reports the pixels of pbm that differ from the expected rows.
+func compareBitmap(t *testing.T, pbm *PBM, rows ...string) {
+	t.Helper()
+	expected := bitmap(rows...)
+	for y := range expected.data {
+		for x := range expected.data[y] {
+			if pbm.data[y][x] != expected.data[y][x] {
+				t.Errorf("Pixel at (%d, %d) is %v", x, y, pbm.data[y][x])
+			}
+		}
+	}
+}
+
+func TestErodeDilate(t *testing.T) {
+	pbm := bitmap(
+		".....",
+		".###.",
+		".###.",
+		".###.",
+		".....",
+	)
+	pbm.Erode(SquareElement(1))
+	compareBitmap(t, pbm,
+		".....",
+		".....",
+		"..#..",
+		".....",
+		".....",
+	)
+	pbm.Dilate(CrossElement(1))
+	compareBitmap(t, pbm,
+		".....",
+		"..#..",
+		".###.",
+		"..#..",
+		".....",
+	)
+}
+
+func TestOpenClose(t *testing.T) {
+	pbm := bitmap(
+		"........",
+		".#......",
+		"...###..",
+		"...#.#..",
+		"...###..",
+		"........",
+	)
+	pbm.Close(SquareElement(1))
+	compareBitmap(t, pbm,
+		"........",
+		".#......",
+		"...###..",
+		"...###..",
+		"...###..",
+		"........",
+	)
+	pbm.Open(SquareElement(1))
+	compareBitmap(t, pbm,
+		"........",
+		"........",
+		"...###..",
+		"...###..",
+		"...###..",
+		"........",
+	)
+}
+
+func TestHitOrMiss(t *testing.T) {
+	// Find isolated pixels
+	pbm := bitmap(
+		"#...##",
+		"......",
+		"..#...",
+	)
+	isolated := SquareElement(1)
+	isolated.data[1][1] = false
+	pbm.HitOrMiss(SquareElement(0), isolated)
+	compareBitmap(t, pbm,
+		"#.....",
+		"......",
+		"..#...",
+	)
+}
+
+func TestThin(t *testing.T) {
+	pbm := bitmap(
+		".......",
+		".#####.",
+		".#####.",
+		".#####.",
+		".......",
+	)
+	pbm.Thin()
+	var count int
+	for y := range pbm.data {
+		for x := range pbm.data[y] {
+			if pbm.data[y][x] {
+				count++
+				if y != 2 {
+					t.Errorf("Pixel at (%d, %d) should have been removed", x, y)
+				}
+			}
+		}
+	}
+	if count == 0 {
+		t.Error("Thinning removed everything")
+	}
+}
+
+func TestSkeletonRebuild(t *testing.T) {
+	pbm := bitmap(
+		".......",
+		".#####.",
+		".#####.",
+		".#####.",
+		".......",
+	)
+	pbm.Skeleton(SquareElement(1))
+	compareBitmap(t, pbm,
+		".......",
+		".......",
+		"..###..",
+		".......",
+		".......",
+	)
+	pbm.Dilate(SquareElement(1))
+	compareBitmap(t, pbm,
+		".......",
+		".#####.",
+		".#####.",
+		".#####.",
+		".......",
+	)
+}
+
+func TestSkeletonOriginOnly(t *testing.T) {
+	// Erosion by the origin alone changes nothing: the image is its own skeleton
+	pbm := bitmap("###", "###")
+	pbm.Skeleton(SquareElement(0))
+	compareBitmap(t, pbm, "###", "###")
+}
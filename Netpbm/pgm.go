@@ -174,8 +174,14 @@ func (pgm *PGM) SetMagicNumber(magicNumber string) {
 	pgm.magicNumber = magicNumber
 }
 
-// SetMaxValue sets the max value of the PPM image.
+// SetMaxValue sets the max value of the PGM image, rescaling the samples to
+// the new range.
 func (pgm *PGM) SetMaxValue(maxValue uint8) {
+	for i := range pgm.data {
+		for j := range pgm.data[i] {
+			pgm.data[i][j] = rescale(pgm.data[i][j], pgm.max, maxValue)
+		}
+	}
 	pgm.max = uint8(maxValue)
 }
 
@@ -202,7 +208,8 @@ func (pgm *PGM) Rotate90CW() {
 }
 
 // /////////
-// ToPBM converts the PGM image to PBM.
+// ToPBM converts the PGM image to PBM: pixels darker than half the max value
+// become black, the others white. ToPBMThreshold offers other thresholds.
 func (pgm *PGM) ToPBM() *PBM {
 	pbm := &PBM{
 		magicNumber: "P1",
@@ -215,7 +222,7 @@ func (pgm *PGM) ToPBM() *PBM {
 		pbm.data[i] = make([]bool, pgm.width)
 		for j := 0; j < pgm.width; j++ {
 			// Convert to pixel value
-			pbm.data[i][j] = pgm.data[i][j] > pgm.max/2
+			pbm.data[i][j] = pgm.data[i][j] < pgm.max/2
 		}
 	}
 
@@ -106,9 +106,9 @@ func TestReadPGM(t *testing.T) {
 	if pgm.max != imagePGMMax {
 		t.Error("Max value not read correctly")
 	}
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testData[i] {
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
 		}
@@ -199,9 +199,9 @@ func TestSavePGM(t *testing.T) {
 	if pgm.max != imagePGMMax {
 		t.Error("Max value not read correctly")
 	}
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testData[i] {
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
 		}
@@ -231,9 +231,9 @@ func TestSavePGM(t *testing.T) {
 	if pgm.max != imagePGMMax {
 		t.Error("Max value not read correctly")
 	}
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testData[i] {
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
 		}
@@ -255,9 +255,9 @@ func TestInvertPGM(t *testing.T) {
 		t.Error(err)
 	}
 	pgm.Invert()
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testInvertPGM[i] {
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
 		}
@@ -270,9 +270,9 @@ func TestFlipPGM(t *testing.T) {
 		t.Error(err)
 	}
 	pgm.Flip()
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testFlipPGM[i] {
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
 		}
@@ -285,9 +285,9 @@ func TestFlopPGM(t *testing.T) {
 		t.Error(err)
 	}
 	pgm.Flop()
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testFlopPGM[i] {
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
 		}
@@ -300,9 +300,9 @@ func TestRotate90CWPGM(t *testing.T) {
 		t.Error(err)
 	}
 	pgm.Rotate90CW()
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testRotate90PGM[i] {
 			fmt.Println(pgm.data[y][x], " | ", testRotate90PGM[i])
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
@@ -331,9 +331,9 @@ func TestSetMaxValuePGM(t *testing.T) {
 	if pgm.max != 5 {
 		t.Error("Max value not set correctly")
 	}
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pgm.data[y][x] != testData[i]*uint8(5)/oldMax {
 			t.Errorf("Pixel at (%d, %d) not read correctly, expected %d, got %d", x, y, uint8(float64(testData[i])*float64(5)/float64(oldMax)), pgm.data[y][x])
 		}
@@ -349,15 +349,15 @@ func TestToPBM(t *testing.T) {
 	if pbm.magicNumber != "P1" {
 		t.Error("Magic number not set correctly")
 	}
-	if pbm.width != pbm.imageWidth {
+	if pbm.width != imageWidth {
 		t.Error("Width not set correctly")
 	}
-	if pbm.height != pbm.imageHeight {
+	if pbm.height != imageHeight {
 		t.Error("Height not set correctly")
 	}
-	for i := 0; i < pbm.imageWidth*pbm.imageHeight; i++ {
-		x := i % pbm.imageWidth
-		y := i / pbm.imageWidth
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
 		if pbm.data[y][x] != (testData[i] < pgm.max/2) {
 			t.Errorf("Pixel at (%d, %d) not read correctly", x, y)
 		}
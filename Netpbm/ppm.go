@@ -127,8 +127,16 @@ func (ppm *PPM) SetMagicNumber(magicNumber string) {
 	ppm.magicNumber = magicNumber
 }
 
-// SetMaxValue sets the max value of the PPM image.
+// SetMaxValue sets the max value of the PPM image, rescaling the samples to
+// the new range.
 func (ppm *PPM) SetMaxValue(maxValue uint8) {
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			ppm.data[y][x].R = rescale(ppm.data[y][x].R, ppm.max, maxValue)
+			ppm.data[y][x].G = rescale(ppm.data[y][x].G, ppm.max, maxValue)
+			ppm.data[y][x].B = rescale(ppm.data[y][x].B, ppm.max, maxValue)
+		}
+	}
 	ppm.max = uint8(maxValue)
 }
 
@@ -145,28 +153,14 @@ func (ppm *PPM) Rotate90CW() {
 	ppm.data = rotated
 }
 
-// ToPBM converts the PPM image to PBM.
+// ToPBM converts the PPM image to PBM like the PGM.ToPBM of its ToPGM
+// conversion: dark pixels become black, the others white.
 func (ppm *PPM) ToPBM() *PBM {
-	pbm := &PBM{
-		width:       ppm.width,
-		height:      ppm.height,
-		magicNumber: "P1",
-		data:        make([][]bool, ppm.height),
-	}
-
-	// Convert color pixels to binary pixels
-	for y := 0; y < ppm.height; y++ {
-		pbm.data[y] = make([]bool, ppm.width)
-		for x := 0; x < ppm.width; x++ {
-			// Convert color pixel to binary pixel (true if color is not black)
-			pbm.data[y][x] = ppm.data[y][x].R != 0 || ppm.data[y][x].G != 0 || ppm.data[y][x].B != 0
-		}
-	}
-
-	return pbm
+	return ppm.ToPGM().ToPBM()
 }
 
-// ToPGM converts the PPM image to PGM.
+// ToPGM converts the PPM image to PGM by averaging the red, green and blue
+// samples equally. ToPGMWith offers perceptual luma formulas.
 func (ppm *PPM) ToPGM() *PGM {
 	pgm := &PGM{
 		width:       ppm.width,
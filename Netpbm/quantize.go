@@ -0,0 +1,412 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// QuantizeMethod selects how the palette of a quantized image is chosen.
+type QuantizeMethod int
+
+const (
+	QuantizeMedianCut QuantizeMethod = iota // splits the colour cube at the median of its widest axis
+	QuantizeOctree                          // merges the least used branches of an octree of colours
+	QuantizeKMeans                          // refines a median cut palette with k-means clustering
+)
+
+// QuantizeOptions configures PPM.Quantize and PPM.Remap.
+type QuantizeOptions struct {
+	Method     QuantizeMethod
+	Colors     int          // size of the palette, ignored by Remap
+	Dither     bool         // dither when mapping pixels to the palette
+	Dithering  DitherMethod // dithering method used when Dither is set
+	Serpentine bool         // alternate the scanning direction of error diffusion
+}
+
+// Quantized is a PPM image reduced to a palette.
+type Quantized struct {
+	Palette []Pixel
+	Indices [][]int // index in Palette of each pixel
+	Image   *PPM    // the image with each pixel replaced by its palette colour
+}
+
+// colorCount is a distinct colour of an image with its number of pixels.
+type colorCount struct {
+	color Pixel
+	count int
+}
+
+// uniqueColors returns the distinct colours of the PPM image sorted by red,
+// green then blue.
+func uniqueColors(ppm *PPM) []colorCount {
+	counts := make(map[Pixel]int)
+	for y := range ppm.data {
+		for _, p := range ppm.data[y] {
+			counts[p]++
+		}
+	}
+	colors := make([]colorCount, 0, len(counts))
+	for p, n := range counts {
+		colors = append(colors, colorCount{p, n})
+	}
+	sort.Slice(colors, func(i, j int) bool {
+		a, b := colors[i].color, colors[j].color
+		if a.R != b.R {
+			return a.R < b.R
+		}
+		if a.G != b.G {
+			return a.G < b.G
+		}
+		return a.B < b.B
+	})
+	return colors
+}
+
+// meanColor returns the average of the colours weighted by their counts.
+func meanColor(colors []colorCount) Pixel {
+	var r, g, b, n float64
+	for _, c := range colors {
+		w := float64(c.count)
+		r += float64(c.color.R) * w
+		g += float64(c.color.G) * w
+		b += float64(c.color.B) * w
+		n += w
+	}
+	if n == 0 {
+		return Pixel{}
+	}
+	return Pixel{uint8(math.Round(r / n)), uint8(math.Round(g / n)), uint8(math.Round(b / n))}
+}
+
+// medianCut splits the colours into at most n boxes and returns their
+// average colours.
+func medianCut(colors []colorCount, n int) []Pixel {
+	boxes := [][]colorCount{colors}
+	for len(boxes) < n {
+		// Split the box with the widest channel range
+		best, bestChannel, bestRange := -1, ChannelRed, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for _, c := range []Channel{ChannelRed, ChannelGreen, ChannelBlue} {
+				lo, hi := 255, 0
+				for _, cc := range box {
+					v := int(c.sample(cc.color))
+					if v < lo {
+						lo = v
+					}
+					if v > hi {
+						hi = v
+					}
+				}
+				if hi-lo > bestRange {
+					best, bestChannel, bestRange = i, c, hi-lo
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		sort.SliceStable(box, func(i, j int) bool {
+			return bestChannel.sample(box[i].color) < bestChannel.sample(box[j].color)
+		})
+		var total, seen int
+		for _, cc := range box {
+			total += cc.count
+		}
+		cut := 1
+		for i, cc := range box[:len(box)-1] {
+			seen += cc.count
+			cut = i + 1
+			if 2*seen >= total {
+				break
+			}
+		}
+		boxes[best] = box[:cut]
+		boxes = append(boxes, box[cut:])
+	}
+	palette := make([]Pixel, len(boxes))
+	for i, box := range boxes {
+		palette[i] = meanColor(box)
+	}
+	return palette
+}
+
+// octreeNode is a node of the colour octree, whose children are selected by
+// one bit of each channel.
+type octreeNode struct {
+	children [8]*octreeNode
+	leaf     bool
+	count    int
+	r, g, b  int
+}
+
+// octree builds an octree of the colours, merges the branches with the
+// fewest pixels until at most n leaves remain and returns their average
+// colours.
+func octree(colors []colorCount, n int) []Pixel {
+	root := &octreeNode{}
+	var levels [8][]*octreeNode
+	leaves := 0
+	for _, cc := range colors {
+		node := root
+		for depth := 0; depth < 8; depth++ {
+			shift := 7 - depth
+			i := int(cc.color.R>>shift&1)<<2 | int(cc.color.G>>shift&1)<<1 | int(cc.color.B>>shift&1)
+			if node.children[i] == nil {
+				node.children[i] = &octreeNode{leaf: depth == 7}
+				if depth == 7 {
+					leaves++
+				} else {
+					levels[depth+1] = append(levels[depth+1], node.children[i])
+				}
+			}
+			node = node.children[i]
+		}
+		node.count += cc.count
+		node.r += int(cc.color.R) * cc.count
+		node.g += int(cc.color.G) * cc.count
+		node.b += int(cc.color.B) * cc.count
+	}
+
+	// subtree sums the pixels below a node
+	var subtree func(node *octreeNode) int
+	subtree = func(node *octreeNode) int {
+		if node.leaf {
+			return node.count
+		}
+		var sum int
+		for _, child := range node.children {
+			if child != nil {
+				sum += subtree(child)
+			}
+		}
+		return sum
+	}
+	for depth := 7; depth > 0 && leaves > n; depth-- {
+		nodes := levels[depth]
+		sort.SliceStable(nodes, func(i, j int) bool { return subtree(nodes[i]) < subtree(nodes[j]) })
+		for _, node := range nodes {
+			if leaves <= n {
+				break
+			}
+			merged := 0
+			for i, child := range node.children {
+				if child == nil {
+					continue
+				}
+				node.count += child.count
+				node.r += child.r
+				node.g += child.g
+				node.b += child.b
+				node.children[i] = nil
+				merged++
+			}
+			node.leaf = true
+			leaves -= merged - 1
+		}
+	}
+
+	var found []*octreeNode
+	var collect func(node *octreeNode)
+	collect = func(node *octreeNode) {
+		if node.leaf {
+			found = append(found, node)
+			return
+		}
+		for _, child := range node.children {
+			if child != nil {
+				collect(child)
+			}
+		}
+	}
+	collect(root)
+	if len(found) > n {
+		// Fewer colours than the children of the root: keep the most used
+		sort.SliceStable(found, func(i, j int) bool { return found[i].count > found[j].count })
+		found = found[:n]
+	}
+	palette := make([]Pixel, len(found))
+	for i, node := range found {
+		palette[i] = Pixel{
+			uint8(math.Round(float64(node.r) / float64(node.count))),
+			uint8(math.Round(float64(node.g) / float64(node.count))),
+			uint8(math.Round(float64(node.b) / float64(node.count))),
+		}
+	}
+	return palette
+}
+
+// kMeans refines the median cut palette of the colours by assigning each
+// colour to its nearest palette entry and moving the entries to the average
+// of their colours, until they no longer change.
+func kMeans(colors []colorCount, n int) []Pixel {
+	palette := medianCut(colors, n)
+	assigned := make([]int, len(colors))
+	for iteration := 0; iteration < 32; iteration++ {
+		for i, cc := range colors {
+			assigned[i] = nearestColor(palette, float64(cc.color.R), float64(cc.color.G), float64(cc.color.B))
+		}
+		clusters := make([][]colorCount, len(palette))
+		for i, cc := range colors {
+			clusters[assigned[i]] = append(clusters[assigned[i]], cc)
+		}
+		changed := false
+		for i, cluster := range clusters {
+			if len(cluster) == 0 {
+				continue
+			}
+			if c := meanColor(cluster); c != palette[i] {
+				palette[i] = c
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return palette
+}
+
+// nearestColor returns the index of the palette entry closest to r, g, b.
+func nearestColor(palette []Pixel, r, g, b float64) int {
+	best, bestDistance := 0, math.Inf(1)
+	for i, p := range palette {
+		dr, dg, db := float64(p.R)-r, float64(p.G)-g, float64(p.B)-b
+		if d := dr*dr + dg*dg + db*db; d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// remap maps every pixel of the PPM image to the nearest palette entry,
+// dithering as configured by opts.
+func (ppm *PPM) remap(palette []Pixel, opts QuantizeOptions) *Quantized {
+	q := &Quantized{
+		Palette: palette,
+		Indices: make([][]int, ppm.height),
+		Image: &PPM{
+			data:        make([][]Pixel, ppm.height),
+			width:       ppm.width,
+			height:      ppm.height,
+			magicNumber: ppm.magicNumber,
+			max:         ppm.max,
+		},
+	}
+	for y := range q.Indices {
+		q.Indices[y] = make([]int, ppm.width)
+		q.Image.data[y] = make([]Pixel, ppm.width)
+	}
+
+	switch matrix := opts.Dithering.thresholdMatrix(); {
+	case !opts.Dither:
+		cache := make(map[Pixel]int)
+		for y := range ppm.data {
+			for x, p := range ppm.data[y] {
+				i, ok := cache[p]
+				if !ok {
+					i = nearestColor(palette, float64(p.R), float64(p.G), float64(p.B))
+					cache[p] = i
+				}
+				q.Indices[y][x] = i
+			}
+		}
+	case matrix != nil:
+		// Ordered dithering offsets pixels by up to half the typical distance
+		// between palette colours along each axis
+		n := len(matrix)
+		spread := float64(ppm.max) / math.Cbrt(float64(len(palette)))
+		for y := range ppm.data {
+			for x, p := range ppm.data[y] {
+				d := ((float64(matrix[y%n][x%n])+0.5)/float64(n*n) - 0.5) * spread
+				q.Indices[y][x] = nearestColor(palette, float64(p.R)+d, float64(p.G)+d, float64(p.B)+d)
+			}
+		}
+	default:
+		planes := planesPPM(ppm)
+		kernel := opts.Dithering.diffusions()
+		for y := 0; y < ppm.height; y++ {
+			reverse := opts.Serpentine && y%2 == 1
+			for i := 0; i < ppm.width; i++ {
+				x, dir := i, 1
+				if reverse {
+					x, dir = ppm.width-1-i, -1
+				}
+				r, g, b := planes[0][y][x], planes[1][y][x], planes[2][y][x]
+				index := nearestColor(palette, r, g, b)
+				q.Indices[y][x] = index
+				p := palette[index]
+				diff := [3]float64{r - float64(p.R), g - float64(p.G), b - float64(p.B)}
+				for _, d := range kernel {
+					nx, ny := x+d.dx*dir, y+d.dy
+					if ny < ppm.height && nx >= 0 && nx < ppm.width {
+						for c := range planes {
+							planes[c][ny][nx] += diff[c] * d.weight
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for y := range q.Indices {
+		for x, i := range q.Indices[y] {
+			q.Image.data[y][x] = palette[i]
+		}
+	}
+	return q
+}
+
+// Quantize reduces the PPM image to a palette of at most opts.Colors
+// colours chosen with opts.Method. Images with fewer colours keep their own.
+func (ppm *PPM) Quantize(opts QuantizeOptions) (*Quantized, error) {
+	if opts.Colors < 1 {
+		return nil, errors.New("palette must have at least one colour")
+	}
+	colors := uniqueColors(ppm)
+	var palette []Pixel
+	switch {
+	case len(colors) <= opts.Colors:
+		palette = make([]Pixel, len(colors))
+		for i, cc := range colors {
+			palette[i] = cc.color
+		}
+	case opts.Method == QuantizeMedianCut:
+		palette = medianCut(colors, opts.Colors)
+	case opts.Method == QuantizeOctree:
+		palette = octree(colors, opts.Colors)
+	case opts.Method == QuantizeKMeans:
+		palette = kMeans(colors, opts.Colors)
+	default:
+		return nil, errors.New("invalid quantization method")
+	}
+	return ppm.remap(palette, opts), nil
+}
+
+// Remap maps every pixel of the PPM image to the nearest of the colours used
+// in the palette image, like pnmremap. The palette colours are rescaled to
+// the max value of the PPM image.
+func (ppm *PPM) Remap(palette *PPM, opts QuantizeOptions) (*Quantized, error) {
+	colors := uniqueColors(palette)
+	if len(colors) == 0 {
+		return nil, errors.New("palette image is empty")
+	}
+	seen := make(map[Pixel]bool)
+	var entries []Pixel
+	for _, cc := range colors {
+		p := Pixel{
+			R: rescale(cc.color.R, palette.max, ppm.max),
+			G: rescale(cc.color.G, palette.max, ppm.max),
+			B: rescale(cc.color.B, palette.max, ppm.max),
+		}
+		if !seen[p] {
+			seen[p] = true
+			entries = append(entries, p)
+		}
+	}
+	return ppm.remap(entries, opts), nil
+}
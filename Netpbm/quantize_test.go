@@ -0,0 +1,93 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+// clustersPPM returns an image made of four clusters of close colours.
+func clustersPPM() *PPM {
+	centres := []Pixel{{20, 20, 20}, {230, 30, 30}, {30, 220, 40}, {40, 40, 230}}
+	ppm := &PPM{data: make([][]Pixel, 8), width: 8, height: 8, magicNumber: "P3", max: 255}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, 8)
+		for x := range ppm.data[y] {
+			c := centres[(y/4)*2+x/4]
+			d := uint8((x + y) % 3)
+			ppm.data[y][x] = Pixel{c.R + d, c.G + d, c.B + d}
+		}
+	}
+	return ppm
+}
+
+func TestQuantize(t *testing.T) {
+	ppm := clustersPPM()
+	for _, method := range []QuantizeMethod{QuantizeMedianCut, QuantizeOctree, QuantizeKMeans} {
+		q, err := ppm.Quantize(QuantizeOptions{Method: method, Colors: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(q.Palette) != 4 {
+			t.Errorf("Method %d: palette has %d colours", method, len(q.Palette))
+			continue
+		}
+		for y := range ppm.data {
+			for x, p := range ppm.data[y] {
+				i := q.Indices[y][x]
+				if q.Image.data[y][x] != q.Palette[i] {
+					t.Fatalf("Method %d: image does not match the index map", method)
+				}
+				c := q.Palette[i]
+				if absDiff(p.R, c.R) > 10 || absDiff(p.G, c.G) > 10 || absDiff(p.B, c.B) > 10 {
+					t.Fatalf("Method %d: %v mapped to %v", method, p, c)
+				}
+			}
+		}
+	}
+	q, err := ppm.Quantize(QuantizeOptions{Colors: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Palette) != 12 {
+		t.Errorf("Palette of an image with 12 colours has %d entries", len(q.Palette))
+	}
+	if _, err := ppm.Quantize(QuantizeOptions{Colors: 0}); err == nil {
+		t.Error("Expected an error for an empty palette")
+	}
+}
+
+func TestRemap(t *testing.T) {
+	// Mid grey remapped to black and white
+	ppm := &PPM{data: make([][]Pixel, 4), width: 4, height: 4, magicNumber: "P3", max: 255}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, 4)
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{128, 128, 128}
+		}
+	}
+	palette := &PPM{data: [][]Pixel{{{0, 0, 0}, {1, 1, 1}}}, width: 2, height: 1, magicNumber: "P3", max: 1}
+	for _, dithering := range []DitherMethod{DitherFloydSteinberg, DitherBayer4} {
+		q, err := ppm.Remap(palette, QuantizeOptions{Dither: true, Dithering: dithering})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(q.Palette) != 2 || q.Palette[1] != (Pixel{255, 255, 255}) {
+			t.Fatalf("Palette is %v", q.Palette)
+		}
+		var white int
+		for y := range q.Indices {
+			for _, i := range q.Indices[y] {
+				white += i
+			}
+		}
+		if white < 6 || white > 10 {
+			t.Errorf("Method %d: %d white pixels out of 16", dithering, white)
+		}
+	}
+	q, err := ppm.Remap(palette, QuantizeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Image.data[0][0] != (Pixel{255, 255, 255}) {
+		t.Errorf("Undithered remap gives %v", q.Image.data[0][0])
+	}
+}
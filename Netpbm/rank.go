@@ -0,0 +1,211 @@
+package Netpbm
+
+import (
+	"math"
+)
+
+// WindowShape selects the neighbourhood used by neighbourhood filters.
+type WindowShape int
+
+const (
+	WindowSquare   WindowShape = iota // (2*radius+1) x (2*radius+1) square
+	WindowCircular                    // pixels within radius of the centre
+)
+
+// windowSpans returns, for each row offset dy from -radius to radius, how
+// far the window extends horizontally on each side of its centre.
+func windowSpans(radius int, shape WindowShape) []int {
+	spans := make([]int, 2*radius+1)
+	for i := range spans {
+		dy := i - radius
+		if shape == WindowCircular {
+			spans[i] = int(math.Floor(math.Sqrt(float64(radius*radius - dy*dy))))
+		} else {
+			spans[i] = radius
+		}
+	}
+	return spans
+}
+
+// clampIndex returns i clamped to [0, n).
+func clampIndex(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+// rankChannel applies a rank filter to a channel of samples in [0, max].
+// Each output sample is the one at the given percentile of its window, the
+// image edges being replicated. A histogram of the window is updated as it
+// slides along each row, so the cost per pixel grows with the window height
+// rather than its area.
+func rankChannel(data [][]uint8, max uint8, radius int, shape WindowShape, percentile float64) [][]uint8 {
+	height := len(data)
+	out := make([][]uint8, height)
+	if height == 0 {
+		return out
+	}
+	width := len(data[0])
+	if radius < 0 {
+		radius = 0
+	}
+	spans := windowSpans(radius, shape)
+	var count int
+	for _, s := range spans {
+		count += 2*s + 1
+	}
+	if percentile < 0 {
+		percentile = 0
+	} else if percentile > 100 {
+		percentile = 100
+	}
+	rank := int(math.Round(percentile / 100 * float64(count-1)))
+
+	histogram := make([]int, int(max)+1)
+	for y := 0; y < height; y++ {
+		out[y] = make([]uint8, width)
+		for i := range histogram {
+			histogram[i] = 0
+		}
+		for i, s := range spans {
+			row := data[clampIndex(y+i-radius, height)]
+			for dx := -s; dx <= s; dx++ {
+				histogram[row[clampIndex(dx, width)]]++
+			}
+		}
+		for x := 0; x < width; x++ {
+			if x > 0 {
+				for i, s := range spans {
+					row := data[clampIndex(y+i-radius, height)]
+					histogram[row[clampIndex(x-s-1, width)]]--
+					histogram[row[clampIndex(x+s, width)]]++
+				}
+			}
+			seen := 0
+			for v, n := range histogram {
+				seen += n
+				if seen > rank {
+					out[y][x] = uint8(v)
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// RankFilter replaces each pixel of the PGM image by the sample at the given
+// percentile, between 0 and 100, of its neighbourhood. The image edges are
+// replicated. A negative radius is taken as zero.
+func (pgm *PGM) RankFilter(radius int, shape WindowShape, percentile float64) {
+	pgm.data = rankChannel(pgm.data, pgm.max, radius, shape, percentile)
+}
+
+// Median replaces each pixel of the PGM image by the median of its
+// neighbourhood, removing salt-and-pepper noise.
+func (pgm *PGM) Median(radius int, shape WindowShape) {
+	pgm.RankFilter(radius, shape, 50)
+}
+
+// MinFilter replaces each pixel of the PGM image by the darkest sample of its
+// neighbourhood.
+func (pgm *PGM) MinFilter(radius int, shape WindowShape) {
+	pgm.RankFilter(radius, shape, 0)
+}
+
+// MaxFilter replaces each pixel of the PGM image by the brightest sample of
+// its neighbourhood.
+func (pgm *PGM) MaxFilter(radius int, shape WindowShape) {
+	pgm.RankFilter(radius, shape, 100)
+}
+
+// channelsPPM returns the red, green and blue samples of the PPM image.
+func channelsPPM(ppm *PPM) [3][][]uint8 {
+	var channels [3][][]uint8
+	for c := range channels {
+		channels[c] = make([][]uint8, ppm.height)
+	}
+	for y := 0; y < ppm.height; y++ {
+		for c := range channels {
+			channels[c][y] = make([]uint8, ppm.width)
+		}
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y][x]
+			channels[0][y][x] = p.R
+			channels[1][y][x] = p.G
+			channels[2][y][x] = p.B
+		}
+	}
+	return channels
+}
+
+// RankFilter replaces each sample of the PPM image by the sample at the given
+// percentile, between 0 and 100, of its neighbourhood in the same channel.
+// The image edges are replicated.
+func (ppm *PPM) RankFilter(radius int, shape WindowShape, percentile float64) {
+	channels := channelsPPM(ppm)
+	for c := range channels {
+		channels[c] = rankChannel(channels[c], ppm.max, radius, shape, percentile)
+	}
+	for y := range ppm.data {
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{R: channels[0][y][x], G: channels[1][y][x], B: channels[2][y][x]}
+		}
+	}
+}
+
+// Median replaces each sample of the PPM image by the median of its
+// neighbourhood in the same channel. The resulting colours may not appear in
+// the original image; see VectorMedian.
+func (ppm *PPM) Median(radius int, shape WindowShape) {
+	ppm.RankFilter(radius, shape, 50)
+}
+
+// VectorMedian replaces each pixel of the PPM image by the pixel of its
+// neighbourhood with the smallest sum of distances to the others, so that no
+// new colour is introduced. The image edges are replicated. Its cost grows
+// with the square of the window area. A negative radius is taken as zero.
+func (ppm *PPM) VectorMedian(radius int, shape WindowShape) {
+	if radius < 0 {
+		radius = 0
+	}
+	spans := windowSpans(radius, shape)
+	out := make([][]Pixel, ppm.height)
+	var window []Pixel
+	for y := 0; y < ppm.height; y++ {
+		out[y] = make([]Pixel, ppm.width)
+		for x := 0; x < ppm.width; x++ {
+			window = window[:0]
+			for i, s := range spans {
+				row := ppm.data[clampIndex(y+i-radius, ppm.height)]
+				for dx := -s; dx <= s; dx++ {
+					window = append(window, row[clampIndex(x+dx, ppm.width)])
+				}
+			}
+			best, bestDistance := window[0], -1
+			for _, p := range window {
+				var distance int
+				for _, q := range window {
+					distance += absDiff(p.R, q.R) + absDiff(p.G, q.G) + absDiff(p.B, q.B)
+				}
+				if bestDistance < 0 || distance < bestDistance {
+					best, bestDistance = p, distance
+				}
+			}
+			out[y][x] = best
+		}
+	}
+	ppm.data = out
+}
+
+// absDiff returns |a - b|.
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -0,0 +1,91 @@
+package Netpbm
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestMedianPGM(t *testing.T) {
+	pgm := &PGM{
+		data: [][]uint8{
+			{5, 5, 5, 5},
+			{5, 255, 5, 5},
+			{5, 5, 0, 5},
+			{5, 5, 5, 5},
+		},
+		width:       4,
+		height:      4,
+		magicNumber: "P2",
+		max:         255,
+	}
+	pgm.Median(1, WindowSquare)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if pgm.data[y][x] != 5 {
+				t.Errorf("Pixel at (%d, %d) is %d, expected 5", x, y, pgm.data[y][x])
+			}
+		}
+	}
+}
+
+func TestRankFilterMatchesSort(t *testing.T) {
+	for _, shape := range []WindowShape{WindowSquare, WindowCircular} {
+		for _, percentile := range []float64{0, 25, 50, 100} {
+			pgm, err := ReadPGM("./testImages/pgm/testP2.pgm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			original := planePGM(pgm)
+			pgm.RankFilter(3, shape, percentile)
+			spans := windowSpans(3, shape)
+			for y := 0; y < pgm.height; y++ {
+				for x := 0; x < pgm.width; x++ {
+					var window []float64
+					for i, s := range spans {
+						for dx := -s; dx <= s; dx++ {
+							window = append(window, original[clampIndex(y+i-3, pgm.height)][clampIndex(x+dx, pgm.width)])
+						}
+					}
+					sort.Float64s(window)
+					expected := window[int(percentile/100*float64(len(window)-1)+0.5)]
+					if float64(pgm.data[y][x]) != expected {
+						t.Fatalf("Shape %d, percentile %v: pixel at (%d, %d) is %d, expected %v", shape, percentile, x, y, pgm.data[y][x], expected)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestVectorMedianPPM(t *testing.T) {
+	a := Pixel{10, 10, 10}
+	b := Pixel{200, 0, 0}
+	ppm := &PPM{
+		data:        [][]Pixel{{a, a, a}, {a, b, a}, {a, a, a}},
+		width:       3,
+		height:      3,
+		magicNumber: "P3",
+		max:         255,
+	}
+	ppm.VectorMedian(1, WindowCircular)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			if ppm.At(x, y) != a {
+				t.Errorf("Pixel at (%d, %d) is %v", x, y, ppm.At(x, y))
+			}
+		}
+	}
+}
+
+func TestRankNegativeRadius(t *testing.T) {
+	pgm := &PGM{data: [][]uint8{{1, 9, 3}}, width: 3, height: 1, magicNumber: "P2", max: 9}
+	pgm.Median(-1, WindowSquare)
+	if pgm.data[0][0] != 1 || pgm.data[0][1] != 9 || pgm.data[0][2] != 3 {
+		t.Errorf("Median with a negative radius changed the image to %v", pgm.data[0])
+	}
+	ppm := &PPM{data: [][]Pixel{{{1, 2, 3}, {4, 5, 6}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	ppm.VectorMedian(-2, WindowCircular)
+	if ppm.data[0][0] != (Pixel{1, 2, 3}) || ppm.data[0][1] != (Pixel{4, 5, 6}) {
+		t.Errorf("Vector median with a negative radius changed the image to %v", ppm.data[0])
+	}
+}
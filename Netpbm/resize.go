@@ -0,0 +1,390 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// Filter selects the resampling filter used when scaling an image.
+type Filter int
+
+const (
+	FilterNearest  Filter = iota // nearest neighbour, fastest, blocky
+	FilterBilinear               // triangle filter
+	FilterBicubic                // Catmull-Rom spline
+	FilterLanczos3               // windowed sinc with three lobes, sharpest
+	FilterBox                    // area averaging weighted by coverage, good for downscaling
+)
+
+// support returns the radius of the filter at a scale of one.
+func (f Filter) support() float64 {
+	switch f {
+	case FilterBilinear:
+		return 1
+	case FilterBicubic:
+		return 2
+	case FilterLanczos3:
+		return 3
+	}
+	return 0.5
+}
+
+// weight evaluates the filter at distance t from its centre.
+func (f Filter) weight(t float64) float64 {
+	t = math.Abs(t)
+	switch f {
+	case FilterBilinear:
+		if t < 1 {
+			return 1 - t
+		}
+		return 0
+	case FilterBicubic:
+		return cubicWeight(t)
+	case FilterLanczos3:
+		if t == 0 {
+			return 1
+		}
+		if t >= 3 {
+			return 0
+		}
+		pt := math.Pi * t
+		return 3 * math.Sin(pt) * math.Sin(pt/3) / (pt * pt)
+	}
+	if t <= 0.5 {
+		return 1
+	}
+	return 0
+}
+
+// contribution lists the source indices and weights making up one output sample.
+type contribution struct {
+	index  []int
+	weight []float64
+}
+
+// contributions computes, for each of the out samples, the source samples of
+// a line of in samples that contribute to it.
+func contributions(in, out int, f Filter) []contribution {
+	scale := float64(in) / float64(out)
+	contribs := make([]contribution, out)
+	if f == FilterNearest {
+		for i := range contribs {
+			j := int(math.Floor((float64(i) + 0.5) * scale))
+			if j >= in {
+				j = in - 1
+			}
+			contribs[i] = contribution{index: []int{j}, weight: []float64{1}}
+		}
+		return contribs
+	}
+	if f == FilterBox {
+		return areaContributions(in, out)
+	}
+	// When shrinking the filter is widened so that every source sample is used.
+	stretch := math.Max(scale, 1)
+	support := f.support() * stretch
+	for i := range contribs {
+		centre := (float64(i)+0.5)*scale - 0.5
+		first := int(math.Ceil(centre - support))
+		last := int(math.Floor(centre + support))
+		var sum float64
+		for j := first; j <= last; j++ {
+			w := f.weight((float64(j) - centre) / stretch)
+			if w == 0 {
+				continue
+			}
+			k := j
+			if k < 0 {
+				k = 0
+			} else if k >= in {
+				k = in - 1
+			}
+			contribs[i].index = append(contribs[i].index, k)
+			contribs[i].weight = append(contribs[i].weight, w)
+			sum += w
+		}
+		if sum != 0 {
+			for k := range contribs[i].weight {
+				contribs[i].weight[k] /= sum
+			}
+		}
+	}
+	return contribs
+}
+
+// areaContributions weights each source sample by the fraction of the area
+// of the output sample it covers, source sample j spanning [j, j+1).
+func areaContributions(in, out int) []contribution {
+	scale := float64(in) / float64(out)
+	contribs := make([]contribution, out)
+	for i := range contribs {
+		lo, hi := float64(i)*scale, float64(i+1)*scale
+		for j := int(math.Floor(lo)); j < in && float64(j) < hi; j++ {
+			w := math.Min(hi, float64(j+1)) - math.Max(lo, float64(j))
+			if w <= 0 {
+				continue
+			}
+			contribs[i].index = append(contribs[i].index, j)
+			contribs[i].weight = append(contribs[i].weight, w/scale)
+		}
+	}
+	return contribs
+}
+
+// resample scales a plane to width x height, filtering rows then columns.
+func resample(plane [][]float64, width, height int, f Filter) [][]float64 {
+	if len(plane) == 0 {
+		return nil
+	}
+	horizontal := contributions(len(plane[0]), width, f)
+	vertical := contributions(len(plane), height, f)
+
+	rows := make([][]float64, len(plane))
+	for y, row := range plane {
+		rows[y] = make([]float64, width)
+		for x, c := range horizontal {
+			var v float64
+			for k, j := range c.index {
+				v += row[j] * c.weight[k]
+			}
+			rows[y][x] = v
+		}
+	}
+	scaled := make([][]float64, height)
+	for y, c := range vertical {
+		scaled[y] = make([]float64, width)
+		for x := 0; x < width; x++ {
+			var v float64
+			for k, j := range c.index {
+				v += rows[j][x] * c.weight[k]
+			}
+			scaled[y][x] = v
+		}
+	}
+	return scaled
+}
+
+// checkSize returns an error if the requested dimensions are not usable.
+func checkSize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("dimensions must be positive")
+	}
+	return nil
+}
+
+// checkSource returns an error if the image to scale has no pixels.
+func checkSource(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("image is empty")
+	}
+	return nil
+}
+
+// fitSize returns the largest size with the aspect ratio of width x height
+// that fits inside maxWidth x maxHeight, or that covers it if cover is true.
+func fitSize(width, height, maxWidth, maxHeight int, cover bool) (int, int) {
+	sx := float64(maxWidth) / float64(width)
+	sy := float64(maxHeight) / float64(height)
+	scale := math.Min(sx, sy)
+	if cover {
+		scale = math.Max(sx, sy)
+	}
+	w := int(math.Round(float64(width) * scale))
+	h := int(math.Round(float64(height) * scale))
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	if cover {
+		// Rounding must never leave the box uncovered
+		if w < maxWidth {
+			w = maxWidth
+		}
+		if h < maxHeight {
+			h = maxHeight
+		}
+	}
+	return w, h
+}
+
+// Resize scales the PGM image to width x height using the given filter.
+func (pgm *PGM) Resize(width, height int, filter Filter) error {
+	if err := checkSource(pgm.width, pgm.height); err != nil {
+		return err
+	}
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	scaled := resample(planePGM(pgm), width, height, filter)
+	data := make([][]uint8, height)
+	for y := range data {
+		data[y] = make([]uint8, width)
+		for x := range data[y] {
+			data[y][x] = toSample(scaled[y][x], pgm.max)
+		}
+	}
+	pgm.width, pgm.height = width, height
+	pgm.data = data
+	return nil
+}
+
+// Fit scales the PGM image, keeping its aspect ratio, so that it fits
+// inside maxWidth x maxHeight.
+func (pgm *PGM) Fit(maxWidth, maxHeight int, filter Filter) error {
+	if err := checkSource(pgm.width, pgm.height); err != nil {
+		return err
+	}
+	if err := checkSize(maxWidth, maxHeight); err != nil {
+		return err
+	}
+	w, h := fitSize(pgm.width, pgm.height, maxWidth, maxHeight, false)
+	return pgm.Resize(w, h, filter)
+}
+
+// Fill scales the PGM image, keeping its aspect ratio, so that it covers
+// width x height, then crops the overflow evenly on both sides.
+func (pgm *PGM) Fill(width, height int, filter Filter) error {
+	if err := checkSource(pgm.width, pgm.height); err != nil {
+		return err
+	}
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	w, h := fitSize(pgm.width, pgm.height, width, height, true)
+	if err := pgm.Resize(w, h, filter); err != nil {
+		return err
+	}
+	left, top := (w-width)/2, (h-height)/2
+	data := pgm.data[top : top+height]
+	for y := range data {
+		data[y] = data[y][left : left+width]
+	}
+	pgm.width, pgm.height = width, height
+	pgm.data = data
+	return nil
+}
+
+// Resize scales the PPM image to width x height using the given filter.
+func (ppm *PPM) Resize(width, height int, filter Filter) error {
+	if err := checkSource(ppm.width, ppm.height); err != nil {
+		return err
+	}
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	planes := planesPPM(ppm)
+	var scaled [3][][]float64
+	for c := range planes {
+		scaled[c] = resample(planes[c], width, height, filter)
+	}
+	data := make([][]Pixel, height)
+	for y := range data {
+		data[y] = make([]Pixel, width)
+		for x := range data[y] {
+			data[y][x] = Pixel{
+				R: toSample(scaled[0][y][x], ppm.max),
+				G: toSample(scaled[1][y][x], ppm.max),
+				B: toSample(scaled[2][y][x], ppm.max),
+			}
+		}
+	}
+	ppm.width, ppm.height = width, height
+	ppm.data = data
+	return nil
+}
+
+// Fit scales the PPM image, keeping its aspect ratio, so that it fits
+// inside maxWidth x maxHeight.
+func (ppm *PPM) Fit(maxWidth, maxHeight int, filter Filter) error {
+	if err := checkSource(ppm.width, ppm.height); err != nil {
+		return err
+	}
+	if err := checkSize(maxWidth, maxHeight); err != nil {
+		return err
+	}
+	w, h := fitSize(ppm.width, ppm.height, maxWidth, maxHeight, false)
+	return ppm.Resize(w, h, filter)
+}
+
+// Fill scales the PPM image, keeping its aspect ratio, so that it covers
+// width x height, then crops the overflow evenly on both sides.
+func (ppm *PPM) Fill(width, height int, filter Filter) error {
+	if err := checkSource(ppm.width, ppm.height); err != nil {
+		return err
+	}
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	w, h := fitSize(ppm.width, ppm.height, width, height, true)
+	if err := ppm.Resize(w, h, filter); err != nil {
+		return err
+	}
+	left, top := (w-width)/2, (h-height)/2
+	data := ppm.data[top : top+height]
+	for y := range data {
+		data[y] = data[y][left : left+width]
+	}
+	ppm.width, ppm.height = width, height
+	ppm.data = data
+	return nil
+}
+
+// Resize scales the PBM image to width x height. Each output pixel is black
+// when at least half of the area it covers in the source image is black.
+func (pbm *PBM) Resize(width, height int) error {
+	if err := checkSource(pbm.width, pbm.height); err != nil {
+		return err
+	}
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	scaled := resample(planePBM(pbm), width, height, FilterBox)
+	data := make([][]bool, height)
+	for y := range data {
+		data[y] = make([]bool, width)
+		for x := range data[y] {
+			data[y][x] = scaled[y][x] >= 0.5
+		}
+	}
+	pbm.width, pbm.height = width, height
+	pbm.data = data
+	return nil
+}
+
+// Fit scales the PBM image, keeping its aspect ratio, so that it fits inside
+// maxWidth x maxHeight.
+func (pbm *PBM) Fit(maxWidth, maxHeight int) error {
+	if err := checkSource(pbm.width, pbm.height); err != nil {
+		return err
+	}
+	if err := checkSize(maxWidth, maxHeight); err != nil {
+		return err
+	}
+	w, h := fitSize(pbm.width, pbm.height, maxWidth, maxHeight, false)
+	return pbm.Resize(w, h)
+}
+
+// Fill scales the PBM image, keeping its aspect ratio, so that it covers
+// width x height, then crops the overflow evenly on both sides.
+func (pbm *PBM) Fill(width, height int) error {
+	if err := checkSource(pbm.width, pbm.height); err != nil {
+		return err
+	}
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	w, h := fitSize(pbm.width, pbm.height, width, height, true)
+	if err := pbm.Resize(w, h); err != nil {
+		return err
+	}
+	left, top := (w-width)/2, (h-height)/2
+	data := pbm.data[top : top+height]
+	for y := range data {
+		data[y] = data[y][left : left+width]
+	}
+	pbm.width, pbm.height = width, height
+	pbm.data = data
+	return nil
+}
@@ -0,0 +1,127 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestResizeBoxPGM(t *testing.T) {
+	pgm := &PGM{
+		data:        [][]uint8{{0, 4, 8, 8}, {4, 8, 8, 8}},
+		width:       4,
+		height:      2,
+		magicNumber: "P2",
+		max:         8,
+	}
+	if err := pgm.Resize(2, 1, FilterBox); err != nil {
+		t.Fatal(err)
+	}
+	if pgm.width != 2 || pgm.height != 1 {
+		t.Fatalf("Wrong size %dx%d", pgm.width, pgm.height)
+	}
+	if pgm.data[0][0] != 4 || pgm.data[0][1] != 8 {
+		t.Errorf("Wrong data %v", pgm.data[0])
+	}
+	if err := pgm.Resize(0, 1, FilterBox); err == nil {
+		t.Error("Expected an error for a zero width")
+	}
+}
+
+func TestResizeBoxPartialCoverage(t *testing.T) {
+	// Each output pixel covers one and a half source pixels
+	pgm := &PGM{data: [][]uint8{{0, 90, 180}}, width: 3, height: 1, magicNumber: "P2", max: 255}
+	if err := pgm.Resize(2, 1, FilterBox); err != nil {
+		t.Fatal(err)
+	}
+	if pgm.data[0][0] != 30 || pgm.data[0][1] != 150 {
+		t.Errorf("Wrong data %v", pgm.data[0])
+	}
+}
+
+func TestResizeUniformPPM(t *testing.T) {
+	for _, filter := range []Filter{FilterNearest, FilterBilinear, FilterBicubic, FilterLanczos3, FilterBox} {
+		ppm := &PPM{
+			data:        [][]Pixel{{{10, 20, 30}, {10, 20, 30}}, {{10, 20, 30}, {10, 20, 30}}},
+			width:       2,
+			height:      2,
+			magicNumber: "P3",
+			max:         255,
+		}
+		if err := ppm.Resize(5, 3, filter); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 5; x++ {
+				if ppm.At(x, y) != (Pixel{10, 20, 30}) {
+					t.Errorf("Filter %d: pixel at (%d, %d) is %v", filter, x, y, ppm.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestFitFillPGM(t *testing.T) {
+	pgm, err := ReadPGM("./testImages/pgm/testP2.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pgm.Fit(10, 4, FilterBilinear); err != nil {
+		t.Fatal(err)
+	}
+	if pgm.width != 4 || pgm.height != 4 {
+		t.Errorf("Fit gave %dx%d, expected 4x4", pgm.width, pgm.height)
+	}
+	if err := pgm.Fill(6, 2, FilterBicubic); err != nil {
+		t.Fatal(err)
+	}
+	if pgm.width != 6 || pgm.height != 2 || len(pgm.data) != 2 || len(pgm.data[0]) != 6 {
+		t.Errorf("Fill gave %dx%d, expected 6x2", pgm.width, pgm.height)
+	}
+}
+
+func TestResizePBM(t *testing.T) {
+	pbm := &PBM{
+		data:        [][]bool{{true, true, false, false}, {true, false, false, false}},
+		width:       4,
+		height:      2,
+		magicNumber: "P1",
+	}
+	if err := pbm.Resize(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !pbm.data[0][0] || pbm.data[0][1] {
+		t.Errorf("Wrong data %v", pbm.data[0])
+	}
+}
+
+func TestFitFillPBM(t *testing.T) {
+	pbm := bitmap("##..", "##..")
+	if err := pbm.Fit(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if pbm.width != 1 || pbm.height != 1 {
+		t.Errorf("Fit gave %dx%d, expected 1x1", pbm.width, pbm.height)
+	}
+	pbm = bitmap("##..", "##..")
+	if err := pbm.Fill(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if pbm.width != 1 || pbm.height != 2 || len(pbm.data[0]) != 1 {
+		t.Errorf("Fill gave %dx%d, expected 1x2", pbm.width, pbm.height)
+	}
+}
+
+func TestResizeEmpty(t *testing.T) {
+	pgm := &PGM{width: 0, height: 0, magicNumber: "P2", max: 255}
+	ppm := &PPM{data: [][]Pixel{{}, {}}, width: 0, height: 2, magicNumber: "P3", max: 255}
+	pbm := &PBM{width: 0, height: 0, magicNumber: "P1"}
+	errs := []error{
+		pgm.Resize(2, 2, FilterBilinear), pgm.Fit(2, 2, FilterBox), pgm.Fill(2, 2, FilterBox),
+		ppm.Resize(2, 2, FilterBilinear), ppm.Fit(2, 2, FilterBox), ppm.Fill(2, 2, FilterBox),
+		pbm.Resize(2, 2), pbm.Fit(2, 2), pbm.Fill(2, 2),
+	}
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("Call %d: expected an error for an empty image", i)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package Netpbm
+
+import "math"
+
+// rotation describes the geometry of a rotation by an arbitrary angle.
+type rotation struct {
+	width, height int     // size of the rotated image
+	cos, sin      float64 // of the angle
+	cx, cy        float64 // centre of the source image
+	ncx, ncy      float64 // centre of the rotated image
+}
+
+// newRotation prepares the rotation of a width x height image by angle
+// degrees clockwise. If expand is true the canvas grows to hold the whole
+// rotated image, otherwise it keeps its original size.
+func newRotation(width, height int, angle float64, expand bool) rotation {
+	rad := angle * math.Pi / 180
+	r := rotation{
+		width:  width,
+		height: height,
+		cos:    math.Cos(rad),
+		sin:    math.Sin(rad),
+		cx:     float64(width-1) / 2,
+		cy:     float64(height-1) / 2,
+	}
+	if expand {
+		w := math.Abs(float64(width)*r.cos) + math.Abs(float64(height)*r.sin)
+		h := math.Abs(float64(width)*r.sin) + math.Abs(float64(height)*r.cos)
+		// Absorb floating point noise so that 90° keeps exact dimensions
+		r.width = int(math.Ceil(w - 1e-9))
+		r.height = int(math.Ceil(h - 1e-9))
+	}
+	r.ncx = float64(r.width-1) / 2
+	r.ncy = float64(r.height-1) / 2
+	return r
+}
+
+// source returns the position in the source image that lands on (x, y) in
+// the rotated image.
+func (r rotation) source(x, y int) (float64, float64) {
+	dx := float64(x) - r.ncx
+	dy := float64(y) - r.ncy
+	return r.cos*dx + r.sin*dy + r.cx, -r.sin*dx + r.cos*dy + r.cy
+}
+
+// Rotate rotates the PGM image by angle degrees clockwise around its centre.
+// Areas not covered by the source image are filled with background.
+func (pgm *PGM) Rotate(angle float64, interp Interpolation, expand bool, background uint8) {
+	r := newRotation(pgm.width, pgm.height, angle, expand)
+	get := edgeSampler(planePGM(pgm), EdgeConstant, float64(background))
+	rotated := make([][]uint8, r.height)
+	for y := range rotated {
+		rotated[y] = make([]uint8, r.width)
+		for x := range rotated[y] {
+			sx, sy := r.source(x, y)
+			rotated[y][x] = toSample(interpolate(get, sx, sy, interp), pgm.max)
+		}
+	}
+	pgm.width, pgm.height = r.width, r.height
+	pgm.data = rotated
+}
+
+// Rotate rotates the PPM image by angle degrees clockwise around its centre.
+// Areas not covered by the source image are filled with background.
+func (ppm *PPM) Rotate(angle float64, interp Interpolation, expand bool, background Pixel) {
+	r := newRotation(ppm.width, ppm.height, angle, expand)
+	planes := planesPPM(ppm)
+	red := edgeSampler(planes[0], EdgeConstant, float64(background.R))
+	green := edgeSampler(planes[1], EdgeConstant, float64(background.G))
+	blue := edgeSampler(planes[2], EdgeConstant, float64(background.B))
+	rotated := make([][]Pixel, r.height)
+	for y := range rotated {
+		rotated[y] = make([]Pixel, r.width)
+		for x := range rotated[y] {
+			sx, sy := r.source(x, y)
+			rotated[y][x] = Pixel{
+				R: toSample(interpolate(red, sx, sy, interp), ppm.max),
+				G: toSample(interpolate(green, sx, sy, interp), ppm.max),
+				B: toSample(interpolate(blue, sx, sy, interp), ppm.max),
+			}
+		}
+	}
+	ppm.width, ppm.height = r.width, r.height
+	ppm.data = rotated
+}
+
+// Rotate rotates the PBM image by angle degrees clockwise around its centre.
+// The interpolated coverage is thresholded at one half so the result stays
+// a bitmap. Areas not covered by the source image are set to background.
+func (pbm *PBM) Rotate(angle float64, interp Interpolation, expand bool, background bool) {
+	r := newRotation(pbm.width, pbm.height, angle, expand)
+	var bg float64
+	if background {
+		bg = 1
+	}
+	get := edgeSampler(planePBM(pbm), EdgeConstant, bg)
+	rotated := make([][]bool, r.height)
+	for y := range rotated {
+		rotated[y] = make([]bool, r.width)
+		for x := range rotated[y] {
+			sx, sy := r.source(x, y)
+			rotated[y][x] = interpolate(get, sx, sy, interp) >= 0.5
+		}
+	}
+	pbm.width, pbm.height = r.width, r.height
+	pbm.data = rotated
+}
@@ -0,0 +1,63 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestRotatePGM(t *testing.T) {
+	pgm := &PGM{
+		data:        [][]uint8{{1, 2, 3}, {4, 5, 6}},
+		width:       3,
+		height:      2,
+		magicNumber: "P2",
+		max:         9,
+	}
+	pgm.Rotate(90, InterpNearest, true, 0)
+	expected := [][]uint8{{4, 1}, {5, 2}, {6, 3}}
+	if pgm.width != 2 || pgm.height != 3 {
+		t.Fatalf("Wrong size %dx%d", pgm.width, pgm.height)
+	}
+	for y := range expected {
+		for x := range expected[y] {
+			if pgm.data[y][x] != expected[y][x] {
+				t.Errorf("Pixel at (%d, %d) is %d, expected %d", x, y, pgm.data[y][x], expected[y][x])
+			}
+		}
+	}
+}
+
+func TestRotateBackgroundPPM(t *testing.T) {
+	white := Pixel{255, 255, 255}
+	ppm := &PPM{
+		data:        [][]Pixel{{white, white, white}, {white, white, white}, {white, white, white}},
+		width:       3,
+		height:      3,
+		magicNumber: "P3",
+		max:         255,
+	}
+	ppm.Rotate(45, InterpBilinear, true, Pixel{255, 0, 0})
+	if ppm.width != 5 || ppm.height != 5 {
+		t.Fatalf("Wrong size %dx%d", ppm.width, ppm.height)
+	}
+	if ppm.At(0, 0) != (Pixel{255, 0, 0}) {
+		t.Errorf("Corner is %v, expected background", ppm.At(0, 0))
+	}
+	if ppm.At(2, 2) != white {
+		t.Errorf("Centre is %v, expected white", ppm.At(2, 2))
+	}
+}
+
+func TestRotatePBM(t *testing.T) {
+	pbm, err := ReadPBM("./testImages/pbm/testP1.pbm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbm.Rotate(180, InterpBicubic, false, false)
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
+		if pbm.data[imageHeight-1-y][imageWidth-1-x] != imageDataP1[i] {
+			t.Errorf("Pixel at (%d, %d) not rotated correctly", x, y)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ColorFrequency is a colour with the number of pixels that use it.
+type ColorFrequency struct {
+	Color Pixel
+	Count int
+}
+
+// ChannelStats summarizes the samples of one channel.
+type ChannelStats struct {
+	Min, Max     uint8
+	Mean, StdDev float64
+}
+
+// sortFrequencies sorts colours from the most to the least used, keeping the
+// order of colours used equally often.
+func sortFrequencies(frequencies []ColorFrequency) {
+	sort.SliceStable(frequencies, func(i, j int) bool {
+		return frequencies[i].Count > frequencies[j].Count
+	})
+}
+
+// ColorHistogram returns every colour of the PPM image with its number of
+// pixels, from the most to the least used, like ppmhist.
+func (ppm *PPM) ColorHistogram() []ColorFrequency {
+	colors := uniqueColors(ppm)
+	frequencies := make([]ColorFrequency, len(colors))
+	for i, cc := range colors {
+		frequencies[i] = ColorFrequency{cc.color, cc.count}
+	}
+	sortFrequencies(frequencies)
+	return frequencies
+}
+
+// UniqueColors returns the number of distinct colours of the PPM image.
+func (ppm *PPM) UniqueColors() int {
+	return len(uniqueColors(ppm))
+}
+
+// DominantColors returns the n colours that best represent the PPM image,
+// found by k-means clustering, with the number of pixels closest to each,
+// from the most to the least used. Fewer colours are returned for images
+// with fewer than n colours.
+func (ppm *PPM) DominantColors(n int) ([]ColorFrequency, error) {
+	q, err := ppm.Quantize(QuantizeOptions{Method: QuantizeKMeans, Colors: n})
+	if err != nil {
+		return nil, err
+	}
+	frequencies := make([]ColorFrequency, len(q.Palette))
+	for i, p := range q.Palette {
+		frequencies[i].Color = p
+	}
+	for y := range q.Indices {
+		for _, i := range q.Indices[y] {
+			frequencies[i].Count++
+		}
+	}
+	// Palette entries that attracted no pixel are dropped
+	used := frequencies[:0]
+	for _, f := range frequencies {
+		if f.Count > 0 {
+			used = append(used, f)
+		}
+	}
+	sortFrequencies(used)
+	return used, nil
+}
+
+// channelStats returns the statistics of the samples counted in counts.
+func channelStats(counts []int) (ChannelStats, error) {
+	var stats ChannelStats
+	var n, sum, squares float64
+	first := true
+	for v, c := range counts {
+		if c == 0 {
+			continue
+		}
+		if first {
+			stats.Min = uint8(v)
+			first = false
+		}
+		stats.Max = uint8(v)
+		n += float64(c)
+		sum += float64(c) * float64(v)
+		squares += float64(c) * float64(v) * float64(v)
+	}
+	if n == 0 {
+		return stats, errors.New("image is empty")
+	}
+	stats.Mean = sum / n
+	stats.StdDev = math.Sqrt(math.Max(squares/n-stats.Mean*stats.Mean, 0))
+	return stats, nil
+}
+
+// Statistics returns the minimum, maximum, mean and standard deviation of
+// the samples of the PGM image.
+func (pgm *PGM) Statistics() (ChannelStats, error) {
+	return channelStats(pgm.Histogram())
+}
+
+// Statistics returns the minimum, maximum, mean and standard deviation of
+// each channel of the PPM image.
+func (ppm *PPM) Statistics() (red, green, blue ChannelStats, err error) {
+	r, g, b := ppm.Histogram()
+	if red, err = channelStats(r); err != nil {
+		return red, green, blue, err
+	}
+	green, _ = channelStats(g)
+	blue, _ = channelStats(b)
+	return red, green, blue, nil
+}
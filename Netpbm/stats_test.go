@@ -0,0 +1,72 @@
+package Netpbm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestColorHistogram(t *testing.T) {
+	ppm := &PPM{
+		data:        [][]Pixel{{{1, 2, 3}, {4, 5, 6}, {1, 2, 3}}, {{0, 0, 0}, {1, 2, 3}, {4, 5, 6}}},
+		width:       3,
+		height:      2,
+		magicNumber: "P3",
+		max:         255,
+	}
+	expected := []ColorFrequency{{Pixel{1, 2, 3}, 3}, {Pixel{4, 5, 6}, 2}, {Pixel{0, 0, 0}, 1}}
+	histogram := ppm.ColorHistogram()
+	if len(histogram) != len(expected) {
+		t.Fatalf("Got %v, expected %v", histogram, expected)
+	}
+	for i := range expected {
+		if histogram[i] != expected[i] {
+			t.Errorf("Got %v, expected %v", histogram, expected)
+			break
+		}
+	}
+	if n := ppm.UniqueColors(); n != 3 {
+		t.Errorf("Got %d unique colours", n)
+	}
+}
+
+func TestDominantColors(t *testing.T) {
+	dominant, err := clustersPPM().DominantColors(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dominant) != 4 {
+		t.Fatalf("Got %v", dominant)
+	}
+	for _, f := range dominant {
+		if f.Count != 16 {
+			t.Errorf("Colour %v covers %d pixels", f.Color, f.Count)
+		}
+	}
+	if _, err := clustersPPM().DominantColors(0); err == nil {
+		t.Error("Expected an error for no colour")
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	pgm := &PGM{data: [][]uint8{{2, 4, 4, 4}, {5, 5, 7, 9}}, width: 4, height: 2, magicNumber: "P2", max: 9}
+	stats, err := pgm.Statistics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Min != 2 || stats.Max != 9 || stats.Mean != 5 || math.Abs(stats.StdDev-2) > 1e-9 {
+		t.Errorf("Got %+v", stats)
+	}
+
+	ppm := &PPM{data: [][]Pixel{{{0, 10, 5}, {10, 10, 5}}}, width: 2, height: 1, magicNumber: "P3", max: 255}
+	red, green, blue, err := ppm.Statistics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if red.Mean != 5 || red.StdDev != 5 || green.Mean != 10 || green.StdDev != 0 || blue.Min != 5 {
+		t.Errorf("Got %+v %+v %+v", red, green, blue)
+	}
+	empty := &PGM{max: 255}
+	if _, err := empty.Statistics(); err == nil {
+		t.Error("Expected an error for an empty image")
+	}
+}
@@ -0,0 +1,236 @@
+package Netpbm
+
+import "math"
+
+// ThresholdMethod selects how the threshold between black and white is chosen
+// when converting to PBM.
+type ThresholdMethod int
+
+const (
+	ThresholdFixed   ThresholdMethod = iota // the given Level
+	ThresholdOtsu                           // maximizes the variance between the two classes
+	ThresholdKapur                          // maximizes the sum of the entropies of the two classes
+	ThresholdSauvola                        // local: mean * (1 + K * (stddev / (max/2) - 1))
+	ThresholdNiblack                        // local: mean + K * stddev
+	ThresholdMeanC                          // local: mean - C
+)
+
+// ThresholdOptions configures the conversion of an image to PBM. Fields not
+// used by the chosen method are ignored. NewThresholdOptions gives the usual
+// parameters of a method.
+type ThresholdOptions struct {
+	Method ThresholdMethod
+	Level  uint8   // threshold of ThresholdFixed
+	Radius int     // half size of the window of local methods, negative taken as 0
+	K      float64 // sensitivity of ThresholdSauvola and ThresholdNiblack
+	C      float64 // offset of ThresholdMeanC
+}
+
+// NewThresholdOptions returns the options of a method with its usual
+// parameters: a window radius of 7 for local methods, K = 0.5 for Sauvola
+// and K = -0.2 for Niblack.
+func NewThresholdOptions(method ThresholdMethod) ThresholdOptions {
+	opts := ThresholdOptions{Method: method}
+	switch method {
+	case ThresholdSauvola:
+		opts.Radius, opts.K = 7, 0.5
+	case ThresholdNiblack:
+		opts.Radius, opts.K = 7, -0.2
+	case ThresholdMeanC:
+		opts.Radius = 7
+	}
+	return opts
+}
+
+// histogram counts the samples of data for each value from 0 to max.
+func histogram(data [][]uint8, max uint8) []int {
+	counts := make([]int, int(max)+1)
+	for _, row := range data {
+		for _, v := range row {
+			if v > max {
+				v = max
+			}
+			counts[v]++
+		}
+	}
+	return counts
+}
+
+// otsuThreshold returns the level maximizing the variance between samples at
+// or below it and samples above it.
+func otsuThreshold(counts []int) uint8 {
+	var total, sum float64
+	for v, n := range counts {
+		total += float64(n)
+		sum += float64(v * n)
+	}
+	var best uint8
+	var bestVariance, w0, sum0 float64
+	for t, n := range counts {
+		w0 += float64(n)
+		sum0 += float64(t * n)
+		w1 := total - w0
+		if w0 == 0 || w1 == 0 {
+			continue
+		}
+		m0 := sum0 / w0
+		m1 := (sum - sum0) / w1
+		variance := w0 * w1 * (m0 - m1) * (m0 - m1)
+		if variance > bestVariance {
+			best, bestVariance = uint8(t), variance
+		}
+	}
+	return best
+}
+
+// kapurThreshold returns the level maximizing the sum of the entropies of the
+// samples at or below it and of the samples above it.
+func kapurThreshold(counts []int) uint8 {
+	var total float64
+	for _, n := range counts {
+		total += float64(n)
+	}
+	if total == 0 {
+		return 0
+	}
+	p := make([]float64, len(counts))
+	for v, n := range counts {
+		p[v] = float64(n) / total
+	}
+	var best uint8
+	bestEntropy := math.Inf(-1)
+	var w0 float64
+	for t := range counts {
+		w0 += p[t]
+		w1 := 1 - w0
+		if w0 <= 0 || w1 <= 1e-12 {
+			continue
+		}
+		var h0, h1 float64
+		for v := 0; v <= t; v++ {
+			if p[v] > 0 {
+				q := p[v] / w0
+				h0 -= q * math.Log(q)
+			}
+		}
+		for v := t + 1; v < len(p); v++ {
+			if p[v] > 0 {
+				q := p[v] / w1
+				h1 -= q * math.Log(q)
+			}
+		}
+		if h0+h1 > bestEntropy {
+			best, bestEntropy = uint8(t), h0+h1
+		}
+	}
+	return best
+}
+
+// localThresholds returns the threshold of each pixel of data computed from
+// the mean and standard deviation of its (2*radius+1) square window, clipped
+// to the image.
+func localThresholds(data [][]uint8, radius int, threshold func(mean, stddev float64) float64) [][]float64 {
+	height := len(data)
+	out := make([][]float64, height)
+	if height == 0 {
+		return out
+	}
+	width := len(data[0])
+	// Summed-area tables of the samples and of their squares
+	sum := make([][]float64, height+1)
+	sq := make([][]float64, height+1)
+	for y := range sum {
+		sum[y] = make([]float64, width+1)
+		sq[y] = make([]float64, width+1)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := float64(data[y][x])
+			sum[y+1][x+1] = v + sum[y][x+1] + sum[y+1][x] - sum[y][x]
+			sq[y+1][x+1] = v*v + sq[y][x+1] + sq[y+1][x] - sq[y][x]
+		}
+	}
+	for y := 0; y < height; y++ {
+		out[y] = make([]float64, width)
+		y0, y1 := clampIndex(y-radius, height), clampIndex(y+radius, height)+1
+		for x := 0; x < width; x++ {
+			x0, x1 := clampIndex(x-radius, width), clampIndex(x+radius, width)+1
+			n := float64((y1 - y0) * (x1 - x0))
+			s := sum[y1][x1] - sum[y0][x1] - sum[y1][x0] + sum[y0][x0]
+			s2 := sq[y1][x1] - sq[y0][x1] - sq[y1][x0] + sq[y0][x0]
+			mean := s / n
+			variance := s2/n - mean*mean
+			if variance < 0 {
+				variance = 0
+			}
+			out[y][x] = threshold(mean, math.Sqrt(variance))
+		}
+	}
+	return out
+}
+
+// ToPBMThreshold converts the PGM image to PBM: pixels whose sample is at or
+// below the threshold become black, the others white, as with ToPBM. It
+// returns the threshold used; for local methods, which compute one threshold
+// per pixel, this is their average.
+func (pgm *PGM) ToPBMThreshold(opts ThresholdOptions) (*PBM, uint8) {
+	pbm := &PBM{
+		magicNumber: "P1",
+		width:       pgm.width,
+		height:      pgm.height,
+		data:        make([][]bool, pgm.height),
+	}
+	for y := range pbm.data {
+		pbm.data[y] = make([]bool, pgm.width)
+	}
+
+	var level uint8
+	switch opts.Method {
+	case ThresholdOtsu:
+		level = otsuThreshold(histogram(pgm.data, pgm.max))
+	case ThresholdKapur:
+		level = kapurThreshold(histogram(pgm.data, pgm.max))
+	case ThresholdSauvola, ThresholdNiblack, ThresholdMeanC:
+		radius := opts.Radius
+		if radius < 0 {
+			radius = 0
+		}
+		var threshold func(mean, stddev float64) float64
+		switch opts.Method {
+		case ThresholdSauvola:
+			r := float64(pgm.max) / 2
+			threshold = func(mean, stddev float64) float64 { return mean * (1 + opts.K*(stddev/r-1)) }
+		case ThresholdNiblack:
+			threshold = func(mean, stddev float64) float64 { return mean + opts.K*stddev }
+		default:
+			threshold = func(mean, stddev float64) float64 { return mean - opts.C }
+		}
+		thresholds := localThresholds(pgm.data, radius, threshold)
+		var total float64
+		for y := range pbm.data {
+			for x := range pbm.data[y] {
+				pbm.data[y][x] = float64(pgm.data[y][x]) <= thresholds[y][x]
+				total += thresholds[y][x]
+			}
+		}
+		if n := pgm.width * pgm.height; n > 0 {
+			return pbm, toSample(total/float64(n), pgm.max)
+		}
+		return pbm, 0
+	default:
+		level = opts.Level
+	}
+
+	for y := range pbm.data {
+		for x := range pbm.data[y] {
+			pbm.data[y][x] = pgm.data[y][x] <= level
+		}
+	}
+	return pbm, level
+}
+
+// ToPBMThreshold converts the PPM image to PBM by thresholding its ToPGM
+// conversion; see PGM.ToPBMThreshold.
+func (ppm *PPM) ToPBMThreshold(opts ThresholdOptions) (*PBM, uint8) {
+	return ppm.ToPGM().ToPBMThreshold(opts)
+}
@@ -0,0 +1,102 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+// bimodalPGM returns a PGM image with dark samples around 20 on the left half
+// and bright samples around 200 on the right half.
+func bimodalPGM() *PGM {
+	pgm := &PGM{data: make([][]uint8, 4), width: 8, height: 4, magicNumber: "P2", max: 255}
+	for y := range pgm.data {
+		pgm.data[y] = []uint8{18, 20, 22, 20, 198, 200, 202, 200}
+	}
+	return pgm
+}
+
+func TestToPBMThresholdGlobal(t *testing.T) {
+	for _, method := range []ThresholdMethod{ThresholdOtsu, ThresholdKapur} {
+		pbm, level := bimodalPGM().ToPBMThreshold(ThresholdOptions{Method: method})
+		if level < 22 || level >= 198 {
+			t.Errorf("Method %d: threshold %d does not separate the modes", method, level)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 8; x++ {
+				if pbm.data[y][x] != (x < 4) {
+					t.Errorf("Method %d: pixel at (%d, %d) is %v", method, x, y, pbm.data[y][x])
+				}
+			}
+		}
+	}
+}
+
+func TestToPBMThresholdFixed(t *testing.T) {
+	pgm, err := ReadPGM("./testImages/pgm/testP2.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbm, level := pgm.ToPBMThreshold(ThresholdOptions{Method: ThresholdFixed, Level: 5})
+	if level != 5 || pbm.magicNumber != "P1" {
+		t.Fatalf("Wrong level %d or magic number %s", level, pbm.magicNumber)
+	}
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
+		if pbm.data[y][x] != (testData[i] <= 5) {
+			t.Errorf("Pixel at (%d, %d) not converted correctly", x, y)
+		}
+	}
+}
+
+func TestToPBMThresholdLocal(t *testing.T) {
+	// Dark text on a background getting brighter from left to right: no global
+	// threshold separates them, a local one does.
+	pgm := &PGM{data: make([][]uint8, 5), width: 10, height: 5, magicNumber: "P2", max: 255}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, 10)
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = uint8(60 + 20*x)
+			if y == 2 {
+				pgm.data[y][x] -= 50
+			}
+		}
+	}
+	niblack := NewThresholdOptions(ThresholdNiblack)
+	niblack.Radius = 1
+	for _, opts := range []ThresholdOptions{
+		{Method: ThresholdMeanC, Radius: 1, C: 5},
+		niblack,
+		{Method: ThresholdSauvola, Radius: 1, K: 0.05},
+	} {
+		pbm, _ := pgm.ToPBMThreshold(opts)
+		for y := 0; y < 5; y++ {
+			for x := 1; x < 9; x++ {
+				if pbm.data[y][x] != (y == 2) {
+					t.Errorf("Method %d: pixel at (%d, %d) is %v", opts.Method, x, y, pbm.data[y][x])
+				}
+			}
+		}
+	}
+}
+
+func TestThresholdOptions(t *testing.T) {
+	if opts := NewThresholdOptions(ThresholdSauvola); opts.Radius != 7 || opts.K != 0.5 {
+		t.Errorf("Sauvola options are %+v", opts)
+	}
+	if opts := NewThresholdOptions(ThresholdOtsu); opts != (ThresholdOptions{Method: ThresholdOtsu}) {
+		t.Errorf("Otsu options are %+v", opts)
+	}
+
+	// Niblack with k = 0 is the plain local mean, which the middle sample
+	// reaches; the default k puts the threshold below it
+	pgm := &PGM{data: [][]uint8{{10, 12, 14}}, width: 3, height: 1, magicNumber: "P2", max: 255}
+	opts := NewThresholdOptions(ThresholdNiblack)
+	opts.Radius = 1
+	if pbm, _ := pgm.ToPBMThreshold(opts); pbm.data[0][1] {
+		t.Error("Middle pixel is black with the default k")
+	}
+	opts.K = 0
+	if pbm, _ := pgm.ToPBMThreshold(opts); !pbm.data[0][1] {
+		t.Error("Middle pixel is white with k = 0")
+	}
+}
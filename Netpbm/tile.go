@@ -0,0 +1,396 @@
+package Netpbm
+
+import "errors"
+
+// PGMTile is a sub-image of a PGM image with the position of its top-left
+// corner in that image.
+type PGMTile struct {
+	Image *PGM
+	X, Y  int
+}
+
+// PPMTile is a sub-image of a PPM image with the position of its top-left
+// corner in that image.
+type PPMTile struct {
+	Image *PPM
+	X, Y  int
+}
+
+// MontageCell describes where an image was placed by a montage.
+type MontageCell struct {
+	Label         string
+	X, Y          int // top-left corner of the image in the montage
+	Width, Height int
+}
+
+// tileOffsets returns the start of each tile of the given size covering a
+// line of n samples, consecutive tiles sharing overlap samples. The last
+// tile is moved back so that it ends on the edge of the image.
+func tileOffsets(n, size, overlap int) []int {
+	if size >= n {
+		return []int{0}
+	}
+	step := size - overlap
+	var offsets []int
+	for pos := 0; ; pos += step {
+		if pos+size >= n {
+			offsets = append(offsets, n-size)
+			break
+		}
+		offsets = append(offsets, pos)
+	}
+	return offsets
+}
+
+// checkTiling returns an error if tiles of the given size and overlap
+// cannot be produced.
+func checkTiling(width, height, overlap int) error {
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	if overlap < 0 || overlap >= width || overlap >= height {
+		return errors.New("overlap must be non-negative and smaller than the tiles")
+	}
+	return nil
+}
+
+// Tile splits the PGM image into a grid of width x height tiles, adjacent
+// tiles sharing overlap pixels. Tiles on the right and bottom edges are moved
+// so that they stay inside the image; images smaller than a tile give a
+// single, smaller tile. The grid is indexed by row then column.
+func (pgm *PGM) Tile(width, height, overlap int) ([][]PGMTile, error) {
+	if err := checkTiling(width, height, overlap); err != nil {
+		return nil, err
+	}
+	xs := tileOffsets(pgm.width, width, overlap)
+	ys := tileOffsets(pgm.height, height, overlap)
+	grid := make([][]PGMTile, len(ys))
+	for j, y0 := range ys {
+		grid[j] = make([]PGMTile, len(xs))
+		h := height
+		if h > pgm.height {
+			h = pgm.height
+		}
+		for i, x0 := range xs {
+			w := width
+			if w > pgm.width {
+				w = pgm.width
+			}
+			tile := &PGM{
+				data:        make([][]uint8, h),
+				width:       w,
+				height:      h,
+				magicNumber: pgm.magicNumber,
+				max:         pgm.max,
+			}
+			for y := range tile.data {
+				tile.data[y] = make([]uint8, w)
+				copy(tile.data[y], pgm.data[y0+y][x0:x0+w])
+			}
+			grid[j][i] = PGMTile{Image: tile, X: x0, Y: y0}
+		}
+	}
+	return grid, nil
+}
+
+// Tile splits the PPM image into a grid of width x height tiles, adjacent
+// tiles sharing overlap pixels. Tiles on the right and bottom edges are moved
+// so that they stay inside the image; images smaller than a tile give a
+// single, smaller tile. The grid is indexed by row then column.
+func (ppm *PPM) Tile(width, height, overlap int) ([][]PPMTile, error) {
+	if err := checkTiling(width, height, overlap); err != nil {
+		return nil, err
+	}
+	xs := tileOffsets(ppm.width, width, overlap)
+	ys := tileOffsets(ppm.height, height, overlap)
+	grid := make([][]PPMTile, len(ys))
+	for j, y0 := range ys {
+		grid[j] = make([]PPMTile, len(xs))
+		h := height
+		if h > ppm.height {
+			h = ppm.height
+		}
+		for i, x0 := range xs {
+			w := width
+			if w > ppm.width {
+				w = ppm.width
+			}
+			tile := &PPM{
+				data:        make([][]Pixel, h),
+				width:       w,
+				height:      h,
+				magicNumber: ppm.magicNumber,
+				max:         ppm.max,
+			}
+			for y := range tile.data {
+				tile.data[y] = make([]Pixel, w)
+				copy(tile.data[y], ppm.data[y0+y][x0:x0+w])
+			}
+			grid[j][i] = PPMTile{Image: tile, X: x0, Y: y0}
+		}
+	}
+	return grid, nil
+}
+
+// AssemblePGM rebuilds a width x height PGM image from tiles, such as the
+// ones returned by Tile after processing. Where tiles overlap their samples
+// are averaged; pixels covered by no tile are 0. Tiles must share the same
+// max value.
+func AssemblePGM(tiles [][]PGMTile, width, height int) (*PGM, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	var first *PGM
+	sum := make([][]float64, height)
+	count := make([][]int, height)
+	for y := range sum {
+		sum[y] = make([]float64, width)
+		count[y] = make([]int, width)
+	}
+	for _, row := range tiles {
+		for _, tile := range row {
+			if first == nil {
+				first = tile.Image
+			} else if tile.Image.max != first.max {
+				return nil, errors.New("tiles have different max values")
+			}
+			for y := 0; y < tile.Image.height; y++ {
+				for x := 0; x < tile.Image.width; x++ {
+					px, py := tile.X+x, tile.Y+y
+					if px < 0 || py < 0 || px >= width || py >= height {
+						continue
+					}
+					sum[py][px] += float64(tile.Image.data[y][x])
+					count[py][px]++
+				}
+			}
+		}
+	}
+	if first == nil {
+		return nil, errors.New("no tile to assemble")
+	}
+	pgm := &PGM{
+		data:        make([][]uint8, height),
+		width:       width,
+		height:      height,
+		magicNumber: first.magicNumber,
+		max:         first.max,
+	}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, width)
+		for x := range pgm.data[y] {
+			if count[y][x] > 0 {
+				pgm.data[y][x] = toSample(sum[y][x]/float64(count[y][x]), pgm.max)
+			}
+		}
+	}
+	return pgm, nil
+}
+
+// AssemblePPM rebuilds a width x height PPM image from tiles, such as the
+// ones returned by Tile after processing. Where tiles overlap their samples
+// are averaged; pixels covered by no tile are black. Tiles must share the
+// same max value.
+func AssemblePPM(tiles [][]PPMTile, width, height int) (*PPM, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	var first *PPM
+	var sum [3][][]float64
+	for c := range sum {
+		sum[c] = make([][]float64, height)
+		for y := range sum[c] {
+			sum[c][y] = make([]float64, width)
+		}
+	}
+	count := make([][]int, height)
+	for y := range count {
+		count[y] = make([]int, width)
+	}
+	for _, row := range tiles {
+		for _, tile := range row {
+			if first == nil {
+				first = tile.Image
+			} else if tile.Image.max != first.max {
+				return nil, errors.New("tiles have different max values")
+			}
+			for y := 0; y < tile.Image.height; y++ {
+				for x := 0; x < tile.Image.width; x++ {
+					px, py := tile.X+x, tile.Y+y
+					if px < 0 || py < 0 || px >= width || py >= height {
+						continue
+					}
+					p := tile.Image.data[y][x]
+					sum[0][py][px] += float64(p.R)
+					sum[1][py][px] += float64(p.G)
+					sum[2][py][px] += float64(p.B)
+					count[py][px]++
+				}
+			}
+		}
+	}
+	if first == nil {
+		return nil, errors.New("no tile to assemble")
+	}
+	ppm := &PPM{
+		data:        make([][]Pixel, height),
+		width:       width,
+		height:      height,
+		magicNumber: first.magicNumber,
+		max:         first.max,
+	}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, width)
+		for x := range ppm.data[y] {
+			if n := float64(count[y][x]); n > 0 {
+				ppm.data[y][x] = Pixel{
+					R: toSample(sum[0][y][x]/n, ppm.max),
+					G: toSample(sum[1][y][x]/n, ppm.max),
+					B: toSample(sum[2][y][x]/n, ppm.max),
+				}
+			}
+		}
+	}
+	return ppm, nil
+}
+
+// montageLayout places count images of the given sizes in a grid of columns
+// cells, each as large as the largest image, separated by spacing pixels.
+// Images are centred in their cell.
+func montageLayout(widths, heights []int, columns, spacing int) (width, height int, cells []MontageCell) {
+	var cellWidth, cellHeight int
+	for i := range widths {
+		if widths[i] > cellWidth {
+			cellWidth = widths[i]
+		}
+		if heights[i] > cellHeight {
+			cellHeight = heights[i]
+		}
+	}
+	if columns > len(widths) {
+		columns = len(widths)
+	}
+	rows := (len(widths) + columns - 1) / columns
+	width = columns*cellWidth + (columns+1)*spacing
+	height = rows*cellHeight + (rows+1)*spacing
+	cells = make([]MontageCell, len(widths))
+	for i := range widths {
+		col, row := i%columns, i/columns
+		cells[i] = MontageCell{
+			X:      spacing + col*(cellWidth+spacing) + (cellWidth-widths[i])/2,
+			Y:      spacing + row*(cellHeight+spacing) + (cellHeight-heights[i])/2,
+			Width:  widths[i],
+			Height: heights[i],
+		}
+	}
+	return width, height, cells
+}
+
+// checkMontage returns an error if a montage cannot be built.
+func checkMontage(count, labels, columns, spacing int) error {
+	if count == 0 {
+		return errors.New("no image to assemble")
+	}
+	if labels != 0 && labels != count {
+		return errors.New("number of labels does not match the number of images")
+	}
+	if columns <= 0 || spacing < 0 {
+		return errors.New("invalid montage layout")
+	}
+	return nil
+}
+
+// MontagePGM assembles images into a grid of the given number of columns,
+// each in a cell as large as the largest image and separated by spacing
+// pixels of background. Samples are rescaled to the largest max value. The
+// returned cells give the label and position of every image, in order;
+// labels may be nil.
+func MontagePGM(images []*PGM, labels []string, columns, spacing int, background uint8) (*PGM, []MontageCell, error) {
+	if err := checkMontage(len(images), len(labels), columns, spacing); err != nil {
+		return nil, nil, err
+	}
+	widths := make([]int, len(images))
+	heights := make([]int, len(images))
+	var max uint8
+	for i, img := range images {
+		widths[i], heights[i] = img.width, img.height
+		if img.max > max {
+			max = img.max
+		}
+	}
+	width, height, cells := montageLayout(widths, heights, columns, spacing)
+	pgm := &PGM{
+		data:        make([][]uint8, height),
+		width:       width,
+		height:      height,
+		magicNumber: images[0].magicNumber,
+		max:         max,
+	}
+	for y := range pgm.data {
+		pgm.data[y] = make([]uint8, width)
+		for x := range pgm.data[y] {
+			pgm.data[y][x] = background
+		}
+	}
+	for i, img := range images {
+		if labels != nil {
+			cells[i].Label = labels[i]
+		}
+		for y := 0; y < img.height; y++ {
+			for x := 0; x < img.width; x++ {
+				pgm.data[cells[i].Y+y][cells[i].X+x] = rescale(img.data[y][x], img.max, max)
+			}
+		}
+	}
+	return pgm, cells, nil
+}
+
+// MontagePPM assembles images into a grid of the given number of columns,
+// each in a cell as large as the largest image and separated by spacing
+// pixels of background. Samples are rescaled to the largest max value. The
+// returned cells give the label and position of every image, in order;
+// labels may be nil.
+func MontagePPM(images []*PPM, labels []string, columns, spacing int, background Pixel) (*PPM, []MontageCell, error) {
+	if err := checkMontage(len(images), len(labels), columns, spacing); err != nil {
+		return nil, nil, err
+	}
+	widths := make([]int, len(images))
+	heights := make([]int, len(images))
+	var max uint8
+	for i, img := range images {
+		widths[i], heights[i] = img.width, img.height
+		if img.max > max {
+			max = img.max
+		}
+	}
+	width, height, cells := montageLayout(widths, heights, columns, spacing)
+	ppm := &PPM{
+		data:        make([][]Pixel, height),
+		width:       width,
+		height:      height,
+		magicNumber: images[0].magicNumber,
+		max:         max,
+	}
+	for y := range ppm.data {
+		ppm.data[y] = make([]Pixel, width)
+		for x := range ppm.data[y] {
+			ppm.data[y][x] = background
+		}
+	}
+	for i, img := range images {
+		if labels != nil {
+			cells[i].Label = labels[i]
+		}
+		for y := 0; y < img.height; y++ {
+			for x := 0; x < img.width; x++ {
+				p := img.data[y][x]
+				ppm.data[cells[i].Y+y][cells[i].X+x] = Pixel{
+					R: rescale(p.R, img.max, max),
+					G: rescale(p.G, img.max, max),
+					B: rescale(p.B, img.max, max),
+				}
+			}
+		}
+	}
+	return ppm, cells, nil
+}
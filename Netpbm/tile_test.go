@@ -0,0 +1,68 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestTileAssemblePGM(t *testing.T) {
+	pgm, err := ReadPGM("./testImages/pgm/testP2.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err := pgm.Tile(6, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Offsets 0, 4, 8, 9 across and 0, 2, ..., 10, 11 down
+	if len(grid) != 7 || len(grid[0]) != 4 {
+		t.Fatalf("Wrong grid %dx%d", len(grid[0]), len(grid))
+	}
+	last := grid[len(grid)-1][len(grid[0])-1]
+	if last.X != 9 || last.Y != 11 || last.Image.width != 6 || last.Image.height != 4 {
+		t.Errorf("Wrong last tile at (%d, %d)", last.X, last.Y)
+	}
+	assembled, err := AssemblePGM(grid, pgm.width, pgm.height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < imageWidth*imageHeight; i++ {
+		x := i % imageWidth
+		y := i / imageWidth
+		if assembled.data[y][x] != testData[i] {
+			t.Errorf("Pixel at (%d, %d) not reassembled correctly", x, y)
+		}
+	}
+	if _, err := pgm.Tile(4, 4, 4); err == nil {
+		t.Error("Expected an error for an overlap as large as the tiles")
+	}
+}
+
+func TestMontagePPM(t *testing.T) {
+	red := Pixel{255, 0, 0}
+	blue := Pixel{0, 0, 1}
+	a := &PPM{data: [][]Pixel{{red, red}, {red, red}}, width: 2, height: 2, magicNumber: "P3", max: 255}
+	b := &PPM{data: [][]Pixel{{blue}}, width: 1, height: 1, magicNumber: "P3", max: 1}
+	ppm, cells, err := MontagePPM([]*PPM{a, b, a}, []string{"a", "b", "c"}, 2, 1, Pixel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ppm.width != 7 || ppm.height != 7 {
+		t.Fatalf("Wrong size %dx%d", ppm.width, ppm.height)
+	}
+	expected := []MontageCell{
+		{Label: "a", X: 1, Y: 1, Width: 2, Height: 2},
+		{Label: "b", X: 4, Y: 1, Width: 1, Height: 1},
+		{Label: "c", X: 1, Y: 4, Width: 2, Height: 2},
+	}
+	for i := range expected {
+		if cells[i] != expected[i] {
+			t.Errorf("Cell %d is %v, expected %v", i, cells[i], expected[i])
+		}
+	}
+	if ppm.At(4, 1) != (Pixel{0, 0, 255}) {
+		t.Errorf("Rescaled pixel is %v", ppm.At(4, 1))
+	}
+	if _, _, err := MontagePPM([]*PPM{a}, []string{"a", "b"}, 1, 0, Pixel{}); err == nil {
+		t.Error("Expected an error for mismatched labels")
+	}
+}
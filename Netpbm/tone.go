@@ -0,0 +1,197 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// curve returns the lookup table of f over [0, max], f working on samples
+// normalized to [0, 1].
+func curve(max uint8, f func(v float64) float64) []uint8 {
+	lut := make([]uint8, int(max)+1)
+	for v := range lut {
+		n := 0.0
+		if max > 0 {
+			n = float64(v) / float64(max)
+		}
+		lut[v] = toSample(f(n)*float64(max), max)
+	}
+	return lut
+}
+
+// gammaCurve returns the lookup table raising normalized samples to 1/gamma.
+func gammaCurve(max uint8, gamma float64) []uint8 {
+	return curve(max, func(v float64) float64 { return math.Pow(v, 1/gamma) })
+}
+
+// brightnessContrastCurve returns the lookup table scaling the distance of
+// samples to the middle grey by contrast, then shifting them by brightness.
+func brightnessContrastCurve(max uint8, brightness, contrast float64) []uint8 {
+	return curve(max, func(v float64) float64 { return (v-0.5)*contrast + 0.5 + brightness })
+}
+
+// stretchCurve returns the lookup table mapping low to 0 and high to max
+// linearly.
+func stretchCurve(max, low, high uint8) []uint8 {
+	lut := make([]uint8, int(max)+1)
+	for v := range lut {
+		if high <= low {
+			lut[v] = uint8(v)
+			continue
+		}
+		lut[v] = toSample(float64(v-int(low))*float64(max)/float64(high-low), max)
+	}
+	return lut
+}
+
+// percentileLevels returns the samples below which lowPercent and above
+// which highPercent percent of the counted samples lie.
+func percentileLevels(counts []int, lowPercent, highPercent float64) (low, high uint8) {
+	var total int
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return 0, uint8(len(counts) - 1)
+	}
+	lowCount := lowPercent / 100 * float64(total)
+	highCount := highPercent / 100 * float64(total)
+	var seen int
+	low, high = 0, uint8(len(counts)-1)
+	for v, n := range counts {
+		seen += n
+		if float64(seen) > lowCount {
+			low = uint8(v)
+			break
+		}
+	}
+	seen = 0
+	for v := len(counts) - 1; v >= 0; v-- {
+		seen += counts[v]
+		if float64(seen) > highCount {
+			high = uint8(v)
+			break
+		}
+	}
+	return low, high
+}
+
+// ApplyLUT replaces each sample v of the PGM image by lut[v]. The table must
+// have an entry for every value up to the max value; entries above the max
+// value are clamped.
+func (pgm *PGM) ApplyLUT(lut []uint8) error {
+	if len(lut) < int(pgm.max)+1 {
+		return errors.New("lookup table is shorter than the range of samples")
+	}
+	clamped := make([]uint8, int(pgm.max)+1)
+	for v := range clamped {
+		clamped[v] = lut[v]
+		if clamped[v] > pgm.max {
+			clamped[v] = pgm.max
+		}
+	}
+	applyLUT(pgm.data, clamped)
+	return nil
+}
+
+// Gamma applies a gamma correction to the PGM image: normalized samples are
+// raised to 1/gamma, so values above 1 brighten the midtones and values
+// below 1 darken them.
+func (pgm *PGM) Gamma(gamma float64) error {
+	if gamma <= 0 {
+		return errors.New("gamma must be positive")
+	}
+	applyLUT(pgm.data, gammaCurve(pgm.max, gamma))
+	return nil
+}
+
+// BrightnessContrast scales the distance of the samples of the PGM image to
+// the middle grey by contrast (1 leaves it unchanged), then adds brightness
+// times the max value (0 leaves it unchanged).
+func (pgm *PGM) BrightnessContrast(brightness, contrast float64) {
+	applyLUT(pgm.data, brightnessContrastCurve(pgm.max, brightness, contrast))
+}
+
+// AutoLevels stretches the samples of the PGM image linearly to the full
+// range, ignoring the darkest lowPercent and brightest highPercent percent of
+// the pixels, which are clipped.
+func (pgm *PGM) AutoLevels(lowPercent, highPercent float64) {
+	low, high := percentileLevels(pgm.Histogram(), lowPercent, highPercent)
+	applyLUT(pgm.data, stretchCurve(pgm.max, low, high))
+}
+
+// Normalize stretches the samples of the PGM image linearly so that the
+// darkest becomes 0 and the brightest the max value.
+func (pgm *PGM) Normalize() {
+	pgm.AutoLevels(0, 0)
+}
+
+// applyLUTs replaces each sample of the PPM image by its entry in the table
+// of its channel.
+func (ppm *PPM) applyLUTs(red, green, blue []uint8) {
+	for y := range ppm.data {
+		for x, p := range ppm.data[y] {
+			ppm.data[y][x] = Pixel{R: red[p.R], G: green[p.G], B: blue[p.B]}
+		}
+	}
+}
+
+// ApplyLUT replaces each sample of the PPM image by its entry in the table of
+// its channel. Each table must have an entry for every value up to the max
+// value; entries above the max value are clamped.
+func (ppm *PPM) ApplyLUT(red, green, blue []uint8) error {
+	var clamped [3][]uint8
+	for c, lut := range [3][]uint8{red, green, blue} {
+		if len(lut) < int(ppm.max)+1 {
+			return errors.New("lookup table is shorter than the range of samples")
+		}
+		clamped[c] = make([]uint8, int(ppm.max)+1)
+		for v := range clamped[c] {
+			clamped[c][v] = lut[v]
+			if clamped[c][v] > ppm.max {
+				clamped[c][v] = ppm.max
+			}
+		}
+	}
+	ppm.applyLUTs(clamped[0], clamped[1], clamped[2])
+	return nil
+}
+
+// Gamma applies a gamma correction to every channel of the PPM image; see
+// PGM.Gamma.
+func (ppm *PPM) Gamma(gamma float64) error {
+	if gamma <= 0 {
+		return errors.New("gamma must be positive")
+	}
+	lut := gammaCurve(ppm.max, gamma)
+	ppm.applyLUTs(lut, lut, lut)
+	return nil
+}
+
+// BrightnessContrast adjusts every channel of the PPM image; see
+// PGM.BrightnessContrast.
+func (ppm *PPM) BrightnessContrast(brightness, contrast float64) {
+	lut := brightnessContrastCurve(ppm.max, brightness, contrast)
+	ppm.applyLUTs(lut, lut, lut)
+}
+
+// AutoLevels stretches the samples of the PPM image linearly to the full
+// range, ignoring the darkest lowPercent and brightest highPercent percent of
+// the samples, which are clipped. The same stretch is applied to all
+// channels so that colours keep their balance.
+func (ppm *PPM) AutoLevels(lowPercent, highPercent float64) {
+	r, g, b := ppm.Histogram()
+	counts := make([]int, len(r))
+	for v := range counts {
+		counts[v] = r[v] + g[v] + b[v]
+	}
+	low, high := percentileLevels(counts, lowPercent, highPercent)
+	lut := stretchCurve(ppm.max, low, high)
+	ppm.applyLUTs(lut, lut, lut)
+}
+
+// Normalize stretches the samples of the PPM image linearly so that the
+// darkest becomes 0 and the brightest the max value.
+func (ppm *PPM) Normalize() {
+	ppm.AutoLevels(0, 0)
+}
@@ -0,0 +1,69 @@
+package Netpbm
+
+import (
+	"testing"
+)
+
+func TestApplyLUTPGM(t *testing.T) {
+	pgm := &PGM{data: [][]uint8{{0, 1, 2, 3}}, width: 4, height: 1, magicNumber: "P2", max: 3}
+	if err := pgm.ApplyLUT([]uint8{3, 2, 9, 0}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint8{3, 2, 3, 0}
+	for x := range expected {
+		if pgm.data[0][x] != expected[x] {
+			t.Fatalf("Got %v, expected %v", pgm.data[0], expected)
+		}
+	}
+	if err := pgm.ApplyLUT([]uint8{0, 1}); err == nil {
+		t.Error("Expected an error for a short table")
+	}
+}
+
+func TestGammaBrightnessContrastPGM(t *testing.T) {
+	pgm := &PGM{data: [][]uint8{{0, 64, 255}}, width: 3, height: 1, magicNumber: "P2", max: 255}
+	if err := pgm.Gamma(2); err != nil {
+		t.Fatal(err)
+	}
+	if pgm.data[0][0] != 0 || pgm.data[0][1] != 128 || pgm.data[0][2] != 255 {
+		t.Errorf("Gamma gave %v", pgm.data[0])
+	}
+	pgm.BrightnessContrast(0, 2)
+	if pgm.data[0][0] != 0 || pgm.data[0][1] != 129 || pgm.data[0][2] != 255 {
+		t.Errorf("Contrast gave %v", pgm.data[0])
+	}
+	pgm.BrightnessContrast(-0.5, 1)
+	if pgm.data[0][0] != 0 || pgm.data[0][1] != 1 || pgm.data[0][2] != 128 {
+		t.Errorf("Brightness gave %v", pgm.data[0])
+	}
+	if err := pgm.Gamma(0); err == nil {
+		t.Error("Expected an error for a zero gamma")
+	}
+}
+
+func TestAutoLevelsPGM(t *testing.T) {
+	pgm := &PGM{data: [][]uint8{{0, 50, 60, 70, 80, 90, 100, 110, 120, 255}}, width: 10, height: 1, magicNumber: "P2", max: 255}
+	pgm.AutoLevels(10, 10)
+	expected := []uint8{0, 0, 36, 73, 109, 146, 182, 219, 255, 255}
+	for x := range expected {
+		if pgm.data[0][x] != expected[x] {
+			t.Fatalf("Got %v, expected %v", pgm.data[0], expected)
+		}
+	}
+}
+
+func TestNormalizePPM(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{10, 20, 30}, {40, 50, 60}}}, width: 2, height: 1, magicNumber: "P3", max: 100}
+	ppm.Normalize()
+	if ppm.At(0, 0) != (Pixel{0, 20, 40}) || ppm.At(1, 0) != (Pixel{60, 80, 100}) {
+		t.Errorf("Got %v", ppm.data[0])
+	}
+}
+
+func TestSetMaxValuePPM(t *testing.T) {
+	ppm := &PPM{data: [][]Pixel{{{0, 100, 255}}}, width: 1, height: 1, magicNumber: "P3", max: 255}
+	ppm.SetMaxValue(15)
+	if ppm.max != 15 || ppm.At(0, 0) != (Pixel{0, 5, 15}) {
+		t.Errorf("Got %v with max %d", ppm.At(0, 0), ppm.max)
+	}
+}
@@ -0,0 +1,299 @@
+package Netpbm
+
+import (
+	"errors"
+	"math"
+)
+
+// Point is a position in image coordinates, x to the right and y down.
+type Point struct {
+	X, Y float64
+}
+
+// Affine is a 2x3 matrix mapping (x, y) to
+// (a[0][0]*x + a[0][1]*y + a[0][2], a[1][0]*x + a[1][1]*y + a[1][2]).
+type Affine [2][3]float64
+
+// IdentityAffine returns the transform that leaves points unchanged.
+func IdentityAffine() Affine {
+	return Affine{{1, 0, 0}, {0, 1, 0}}
+}
+
+// TranslateAffine returns a translation by (tx, ty).
+func TranslateAffine(tx, ty float64) Affine {
+	return Affine{{1, 0, tx}, {0, 1, ty}}
+}
+
+// ScaleAffine returns a scaling by sx horizontally and sy vertically.
+func ScaleAffine(sx, sy float64) Affine {
+	return Affine{{sx, 0, 0}, {0, sy, 0}}
+}
+
+// ShearAffine returns a shear moving x by kx*y and y by ky*x.
+func ShearAffine(kx, ky float64) Affine {
+	return Affine{{1, kx, 0}, {ky, 1, 0}}
+}
+
+// RotateAffine returns a rotation by angle degrees clockwise around the origin.
+func RotateAffine(angle float64) Affine {
+	rad := angle * math.Pi / 180
+	cos, sin := math.Cos(rad), math.Sin(rad)
+	return Affine{{cos, -sin, 0}, {sin, cos, 0}}
+}
+
+// Then returns the transform applying a first and b second.
+func (a Affine) Then(b Affine) Affine {
+	return Affine{
+		{
+			b[0][0]*a[0][0] + b[0][1]*a[1][0],
+			b[0][0]*a[0][1] + b[0][1]*a[1][1],
+			b[0][0]*a[0][2] + b[0][1]*a[1][2] + b[0][2],
+		},
+		{
+			b[1][0]*a[0][0] + b[1][1]*a[1][0],
+			b[1][0]*a[0][1] + b[1][1]*a[1][1],
+			b[1][0]*a[0][2] + b[1][1]*a[1][2] + b[1][2],
+		},
+	}
+}
+
+// Apply returns the image of p by the transform.
+func (a Affine) Apply(p Point) Point {
+	return Point{
+		X: a[0][0]*p.X + a[0][1]*p.Y + a[0][2],
+		Y: a[1][0]*p.X + a[1][1]*p.Y + a[1][2],
+	}
+}
+
+// Invert returns the inverse transform, or an error if the matrix is singular.
+func (a Affine) Invert() (Affine, error) {
+	det := a[0][0]*a[1][1] - a[0][1]*a[1][0]
+	if math.Abs(det) < 1e-12 {
+		return Affine{}, errors.New("affine transform is not invertible")
+	}
+	inv := Affine{
+		{a[1][1] / det, -a[0][1] / det, 0},
+		{-a[1][0] / det, a[0][0] / det, 0},
+	}
+	inv[0][2] = -(inv[0][0]*a[0][2] + inv[0][1]*a[1][2])
+	inv[1][2] = -(inv[1][0]*a[0][2] + inv[1][1]*a[1][2])
+	return inv, nil
+}
+
+// Homography is a 3x3 projective transform acting on homogeneous coordinates.
+type Homography [3][3]float64
+
+// HomographyFromPoints returns the projective transform mapping each of the
+// four src points onto the matching dst point. No three points of either set
+// may be collinear.
+func HomographyFromPoints(src, dst [4]Point) (Homography, error) {
+	// Solve the 8x8 linear system for h00..h21, h22 being fixed to 1
+	var m [8][9]float64
+	for i := 0; i < 4; i++ {
+		x, y := src[i].X, src[i].Y
+		u, v := dst[i].X, dst[i].Y
+		m[2*i] = [9]float64{x, y, 1, 0, 0, 0, -u * x, -u * y, u}
+		m[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -v * x, -v * y, v}
+	}
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return Homography{}, errors.New("points are degenerate")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := 0; row < 8; row++ {
+			if row == col {
+				continue
+			}
+			f := m[row][col] / m[col][col]
+			for k := col; k < 9; k++ {
+				m[row][k] -= f * m[col][k]
+			}
+		}
+	}
+	var h Homography
+	for i := 0; i < 8; i++ {
+		h[i/3][i%3] = m[i][8] / m[i][i]
+	}
+	h[2][2] = 1
+	return h, nil
+}
+
+// Apply returns the image of p by the transform. ok is false when p is
+// mapped to infinity.
+func (h Homography) Apply(p Point) (q Point, ok bool) {
+	w := h[2][0]*p.X + h[2][1]*p.Y + h[2][2]
+	if math.Abs(w) < 1e-12 {
+		return Point{}, false
+	}
+	return Point{
+		X: (h[0][0]*p.X + h[0][1]*p.Y + h[0][2]) / w,
+		Y: (h[1][0]*p.X + h[1][1]*p.Y + h[1][2]) / w,
+	}, true
+}
+
+// Invert returns the inverse transform, or an error if the matrix is singular.
+func (h Homography) Invert() (Homography, error) {
+	det := h[0][0]*(h[1][1]*h[2][2]-h[1][2]*h[2][1]) -
+		h[0][1]*(h[1][0]*h[2][2]-h[1][2]*h[2][0]) +
+		h[0][2]*(h[1][0]*h[2][1]-h[1][1]*h[2][0])
+	if math.Abs(det) < 1e-12 {
+		return Homography{}, errors.New("homography is not invertible")
+	}
+	var inv Homography
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			// Transposed cofactor
+			a, b := (j+1)%3, (j+2)%3
+			c, d := (i+1)%3, (i+2)%3
+			inv[i][j] = (h[a][c]*h[b][d] - h[a][d]*h[b][c]) / det
+		}
+	}
+	return inv, nil
+}
+
+// inverseMapping returns, for a destination pixel, the source position it is
+// read from. ok is false when there is no such position.
+type inverseMapping func(x, y int) (sx, sy float64, ok bool)
+
+// affineMapping returns the inverse mapping of the source-to-destination
+// transform a.
+func affineMapping(a Affine) (inverseMapping, error) {
+	inv, err := a.Invert()
+	if err != nil {
+		return nil, err
+	}
+	return func(x, y int) (float64, float64, bool) {
+		p := inv.Apply(Point{float64(x), float64(y)})
+		return p.X, p.Y, true
+	}, nil
+}
+
+// homographyMapping returns the inverse mapping of the source-to-destination
+// transform h.
+func homographyMapping(h Homography) (inverseMapping, error) {
+	inv, err := h.Invert()
+	if err != nil {
+		return nil, err
+	}
+	return func(x, y int) (float64, float64, bool) {
+		p, ok := inv.Apply(Point{float64(x), float64(y)})
+		return p.X, p.Y, ok
+	}, nil
+}
+
+// warpPGM renders a width x height PGM whose pixels are read from pgm through m.
+func warpPGM(pgm *PGM, m inverseMapping, width, height int, interp Interpolation, edge EdgeMode, fill uint8) [][]uint8 {
+	get := edgeSampler(planePGM(pgm), edge, float64(fill))
+	data := make([][]uint8, height)
+	for y := range data {
+		data[y] = make([]uint8, width)
+		for x := range data[y] {
+			sx, sy, ok := m(x, y)
+			if !ok {
+				data[y][x] = fill
+				continue
+			}
+			data[y][x] = toSample(interpolate(get, sx, sy, interp), pgm.max)
+		}
+	}
+	return data
+}
+
+// warpPPM renders a width x height PPM whose pixels are read from ppm through m.
+func warpPPM(ppm *PPM, m inverseMapping, width, height int, interp Interpolation, edge EdgeMode, fill Pixel) [][]Pixel {
+	planes := planesPPM(ppm)
+	red := edgeSampler(planes[0], edge, float64(fill.R))
+	green := edgeSampler(planes[1], edge, float64(fill.G))
+	blue := edgeSampler(planes[2], edge, float64(fill.B))
+	data := make([][]Pixel, height)
+	for y := range data {
+		data[y] = make([]Pixel, width)
+		for x := range data[y] {
+			sx, sy, ok := m(x, y)
+			if !ok {
+				data[y][x] = fill
+				continue
+			}
+			data[y][x] = Pixel{
+				R: toSample(interpolate(red, sx, sy, interp), ppm.max),
+				G: toSample(interpolate(green, sx, sy, interp), ppm.max),
+				B: toSample(interpolate(blue, sx, sy, interp), ppm.max),
+			}
+		}
+	}
+	return data
+}
+
+// WarpAffine transforms the PGM image by a, which maps source coordinates to
+// destination coordinates, onto a width x height canvas. Destination pixels
+// read outside of the source are resolved by edge, fill being used by
+// EdgeConstant.
+func (pgm *PGM) WarpAffine(a Affine, width, height int, interp Interpolation, edge EdgeMode, fill uint8) error {
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	m, err := affineMapping(a)
+	if err != nil {
+		return err
+	}
+	pgm.data = warpPGM(pgm, m, width, height, interp, edge, fill)
+	pgm.width, pgm.height = width, height
+	return nil
+}
+
+// WarpPerspective transforms the PGM image by h, which maps source
+// coordinates to destination coordinates, onto a width x height canvas.
+// Destination pixels read outside of the source are resolved by edge, fill
+// being used by EdgeConstant.
+func (pgm *PGM) WarpPerspective(h Homography, width, height int, interp Interpolation, edge EdgeMode, fill uint8) error {
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	m, err := homographyMapping(h)
+	if err != nil {
+		return err
+	}
+	pgm.data = warpPGM(pgm, m, width, height, interp, edge, fill)
+	pgm.width, pgm.height = width, height
+	return nil
+}
+
+// WarpAffine transforms the PPM image by a, which maps source coordinates to
+// destination coordinates, onto a width x height canvas. Destination pixels
+// read outside of the source are resolved by edge, fill being used by
+// EdgeConstant.
+func (ppm *PPM) WarpAffine(a Affine, width, height int, interp Interpolation, edge EdgeMode, fill Pixel) error {
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	m, err := affineMapping(a)
+	if err != nil {
+		return err
+	}
+	ppm.data = warpPPM(ppm, m, width, height, interp, edge, fill)
+	ppm.width, ppm.height = width, height
+	return nil
+}
+
+// WarpPerspective transforms the PPM image by h, which maps source
+// coordinates to destination coordinates, onto a width x height canvas.
+// Destination pixels read outside of the source are resolved by edge, fill
+// being used by EdgeConstant.
+func (ppm *PPM) WarpPerspective(h Homography, width, height int, interp Interpolation, edge EdgeMode, fill Pixel) error {
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	m, err := homographyMapping(h)
+	if err != nil {
+		return err
+	}
+	ppm.data = warpPPM(ppm, m, width, height, interp, edge, fill)
+	ppm.width, ppm.height = width, height
+	return nil
+}
@@ -0,0 +1,110 @@
+package Netpbm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWarpAffineEdgesPGM(t *testing.T) {
+	expected := map[EdgeMode][]uint8{
+		EdgeConstant: {9, 1, 2, 3},
+		EdgeClamp:    {1, 1, 2, 3},
+		EdgeWrap:     {4, 1, 2, 3},
+		EdgeReflect:  {1, 1, 2, 3},
+	}
+	for edge, row := range expected {
+		pgm := &PGM{
+			data:        [][]uint8{{1, 2, 3, 4}},
+			width:       4,
+			height:      1,
+			magicNumber: "P2",
+			max:         9,
+		}
+		if err := pgm.WarpAffine(TranslateAffine(1, 0), 4, 1, InterpNearest, edge, 9); err != nil {
+			t.Fatal(err)
+		}
+		for x := range row {
+			if pgm.data[0][x] != row[x] {
+				t.Errorf("Edge mode %d: got %v, expected %v", edge, pgm.data[0], row)
+				break
+			}
+		}
+	}
+}
+
+func TestAffineCompose(t *testing.T) {
+	a := ScaleAffine(2, 3).Then(RotateAffine(90)).Then(TranslateAffine(5, -1))
+	p := a.Apply(Point{1, 1})
+	if math.Abs(p.X-2) > 1e-9 || math.Abs(p.Y-1) > 1e-9 {
+		t.Errorf("Got %v, expected {2 1}", p)
+	}
+	inv, err := a.Invert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := inv.Apply(p)
+	if math.Abs(q.X-1) > 1e-9 || math.Abs(q.Y-1) > 1e-9 {
+		t.Errorf("Inverse gave %v, expected {1 1}", q)
+	}
+	if _, err := ScaleAffine(0, 1).Invert(); err == nil {
+		t.Error("Expected an error for a singular matrix")
+	}
+}
+
+func TestHomographyFromPoints(t *testing.T) {
+	src := [4]Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	dst := [4]Point{{1, 2}, {8, 0}, {9, 9}, {0, 7}}
+	h, err := HomographyFromPoints(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range src {
+		p, ok := h.Apply(src[i])
+		if !ok || math.Abs(p.X-dst[i].X) > 1e-9 || math.Abs(p.Y-dst[i].Y) > 1e-9 {
+			t.Errorf("Point %d mapped to %v, expected %v", i, p, dst[i])
+		}
+	}
+	collinear := [4]Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}
+	if _, err := HomographyFromPoints(collinear, dst); err == nil {
+		t.Error("Expected an error for collinear points")
+	}
+}
+
+func TestWarpPerspectivePPM(t *testing.T) {
+	ppm := &PPM{
+		data:        [][]Pixel{{{1, 2, 3}, {4, 5, 6}}, {{7, 8, 9}, {10, 11, 12}}},
+		width:       2,
+		height:      2,
+		magicNumber: "P3",
+		max:         255,
+	}
+	// Scale by two with a projective transform
+	src := [4]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	dst := [4]Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}
+	h, err := HomographyFromPoints(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ppm.WarpPerspective(h, 3, 3, InterpBilinear, EdgeClamp, Pixel{}); err != nil {
+		t.Fatal(err)
+	}
+	if ppm.At(0, 0) != (Pixel{1, 2, 3}) || ppm.At(2, 2) != (Pixel{10, 11, 12}) {
+		t.Errorf("Corners are %v and %v", ppm.At(0, 0), ppm.At(2, 2))
+	}
+	if ppm.At(1, 1) != (Pixel{6, 7, 8}) {
+		t.Errorf("Centre is %v, expected {6 7 8}", ppm.At(1, 1))
+	}
+}
+
+func TestWarpAffineEmptySource(t *testing.T) {
+	// Every pixel falls outside an empty source and gets the fill value
+	for _, edge := range []EdgeMode{EdgeConstant, EdgeClamp, EdgeWrap, EdgeReflect} {
+		pgm := &PGM{data: [][]uint8{{}, {}}, width: 0, height: 2, magicNumber: "P2", max: 255}
+		if err := pgm.WarpAffine(IdentityAffine(), 2, 2, InterpBilinear, edge, 7); err != nil {
+			t.Fatal(err)
+		}
+		if pgm.data[0][0] != 7 || pgm.data[1][1] != 7 {
+			t.Errorf("Edge mode %d: got %v", edge, pgm.data)
+		}
+	}
+}